@@ -0,0 +1,317 @@
+package duo
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Array is a slice that is stored as a Postgres array (for example, text[], int8[]
+// or uuid[]) using the array literal syntax, and as a JSON array in MySQL and SQLite.
+// It can be used as a struct field, or passed as an argument to the builders:
+//
+//	type Post struct {
+//		ID   int
+//		Tags duo.Array[string]
+//	}
+//
+//	duo.Dialect(duo.Postgres).
+//		Select().
+//		From(duo.Table("posts")).
+//		Where(duo.P(func(b *duo.Builder) {
+//			b.Ident("tags").WriteString(" && ").Arg(duo.Array[string]{"go", "sql"})
+//		}))
+//
+// Struct fields of type []T (where T is a basic type, time.Time or a sql.Scanner) are
+// scanned the same way, and they are encoded as arrays by the SetStruct methods.
+type Array[T any] []T
+
+// Scan implements the sql.Scanner interface. It accepts both
+// Postgres array literals and JSON arrays.
+func (a *Array[T]) Scan(src any) error {
+	return scanArray(src, reflect.ValueOf(a).Elem(), false)
+}
+
+// Value implements the driver.Valuer interface. The array is
+// encoded using the Postgres array literal syntax.
+func (a Array[T]) Value() (driver.Value, error) {
+	return arrayValue(reflect.ValueOf(a), Postgres)
+}
+
+// ParamValue implements the ParamValuer interface. The array is encoded as
+// a Postgres array literal for Postgres, and as a JSON array otherwise.
+func (a Array[T]) ParamValue(info *StmtInfo) (driver.Value, error) {
+	return arrayValue(reflect.ValueOf(a), info.Dialect)
+}
+
+// arrayArg is the argument form of slice fields that are encoded as arrays.
+type arrayArg struct {
+	v reflect.Value
+}
+
+// Value implements the driver.Valuer interface.
+func (a arrayArg) Value() (driver.Value, error) {
+	return arrayValue(a.v, Postgres)
+}
+
+// ParamValue implements the ParamValuer interface.
+func (a arrayArg) ParamValue(info *StmtInfo) (driver.Value, error) {
+	return arrayValue(a.v, info.Dialect)
+}
+
+// arrayType reports if the given type is a slice that is scanned
+// from (and encoded to) an array column.
+func arrayType(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Slice || typ.Elem().Kind() == reflect.Uint8 || typ.Implements(scannerType) || reflect.PtrTo(typ).Implements(scannerType) {
+		return false
+	}
+	e := indirect(typ.Elem())
+	switch k := e.Kind(); {
+	case e == timeType, reflect.PtrTo(e).Implements(scannerType):
+	case k == reflect.String, k >= reflect.Bool && k <= reflect.Float64:
+	case k == reflect.Slice && e.Elem().Kind() == reflect.Uint8:
+	default:
+		return false
+	}
+	return true
+}
+
+// scanArray decodes the given array value into dst (an addressable slice). If strict
+// is set, NULL elements of non-nullable element types fail with ErrUnexpectedNull.
+func scanArray(src any, dst reflect.Value, strict bool) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	default:
+		return fmt.Errorf("sql/array: unsupported type %T for %s", src, dst.Type())
+	}
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") {
+		return json.Unmarshal([]byte(s), dst.Addr().Interface())
+	}
+	elems, err := parseArray(s)
+	if err != nil {
+		return err
+	}
+	var (
+		et = dst.Type().Elem()
+		v  = reflect.MakeSlice(dst.Type(), len(elems), len(elems))
+	)
+	strict = strict && !nillable(et) && !reflect.PtrTo(et).Implements(scannerType)
+	for i, e := range elems {
+		if e == nil {
+			if strict {
+				return fmt.Errorf("sql/array: element %d: %w", i, ErrUnexpectedNull)
+			}
+			continue
+		}
+		if err := setElem(v.Index(i), *e); err != nil {
+			return fmt.Errorf("sql/array: element %d: %w", i, err)
+		}
+	}
+	dst.Set(v)
+	return nil
+}
+
+// parseArray parses a one-dimensional Postgres array literal. NULL elements are
+// returned as nil. For example, {a,"b c",NULL} => ["a", "b c", nil].
+func parseArray(s string) ([]*string, error) {
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("sql/array: invalid array literal %q", s)
+	}
+	var (
+		elems []*string
+		in    = s[1 : len(s)-1]
+	)
+	if strings.TrimSpace(in) == "" {
+		return elems, nil
+	}
+	for i := 0; i <= len(in); i++ {
+		var b strings.Builder
+		switch {
+		case i < len(in) && in[i] == '{':
+			return nil, errors.New("sql/array: multi-dimensional arrays are not supported")
+		case i < len(in) && in[i] == '"':
+			for i++; i < len(in) && in[i] != '"'; i++ {
+				if in[i] == '\\' && i+1 < len(in) {
+					i++
+				}
+				b.WriteByte(in[i])
+			}
+			if i == len(in) {
+				return nil, fmt.Errorf("sql/array: unterminated quoted element in %q", s)
+			}
+			e := b.String()
+			elems = append(elems, &e)
+			i++
+		default:
+			j := strings.IndexByte(in[i:], ',')
+			if j == -1 {
+				j = len(in) - i
+			}
+			e := strings.TrimSpace(in[i : i+j])
+			if strings.EqualFold(e, "NULL") {
+				elems = append(elems, nil)
+			} else {
+				elems = append(elems, &e)
+			}
+			i += j
+		}
+		if i < len(in) && in[i] != ',' {
+			return nil, fmt.Errorf("sql/array: unexpected character %q in %q", in[i], s)
+		}
+	}
+	return elems, nil
+}
+
+// arrayTimeLayouts are the layouts used for parsing time elements. Postgres
+// formats the time zone offsets of timestamptz elements without minutes.
+var arrayTimeLayouts = append(timeLayouts[:len(timeLayouts):len(timeLayouts)], "2006-01-02 15:04:05.999999999Z07")
+
+// setElem sets the given array element from its textual representation.
+func setElem(v reflect.Value, s string) error {
+	if sc, ok := v.Addr().Interface().(sql.Scanner); ok && v.Kind() != reflect.Ptr {
+		return sc.Scan(s)
+	}
+	switch k := v.Kind(); {
+	case k == reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		return setElem(v.Elem(), s)
+	case v.Type() == timeType:
+		for _, layout := range arrayTimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				v.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("cannot parse %q as time.Time", s)
+	case k == reflect.String:
+		v.SetString(s)
+	case k == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case k >= reflect.Int && k <= reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case k >= reflect.Uint && k <= reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case k == reflect.Float32 || k == reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case k == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		b := []byte(s)
+		if strings.HasPrefix(s, `\x`) {
+			var err error
+			if b, err = hex.DecodeString(s[2:]); err != nil {
+				return err
+			}
+		}
+		v.SetBytes(b)
+	default:
+		return fmt.Errorf("unsupported element type %s", v.Type())
+	}
+	return nil
+}
+
+// arrayValue encodes the given slice for the given dialect.
+func arrayValue(v reflect.Value, dialect string) (driver.Value, error) {
+	if v.IsNil() {
+		return nil, nil
+	}
+	if dialect != Postgres {
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if err := formatElem(&b, v.Index(i)); err != nil {
+			return nil, fmt.Errorf("sql/array: element %d: %w", i, err)
+		}
+	}
+	b.WriteByte('}')
+	return b.String(), nil
+}
+
+// formatElem writes the array literal representation of the given element.
+func formatElem(b *strings.Builder, v reflect.Value) error {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		b.WriteString("NULL")
+		return nil
+	}
+	if vr, ok := v.Interface().(driver.Valuer); ok {
+		dv, err := vr.Value()
+		if err != nil {
+			return err
+		}
+		if dv == nil {
+			b.WriteString("NULL")
+			return nil
+		}
+		v = reflect.ValueOf(dv)
+	}
+	v = reflect.Indirect(v)
+	switch k := v.Kind(); {
+	case v.Type() == timeType:
+		quoteElem(b, v.Interface().(time.Time).Format(time.RFC3339Nano))
+	case k == reflect.String:
+		quoteElem(b, v.String())
+	case k == reflect.Bool:
+		b.WriteString(strconv.FormatBool(v.Bool()))
+	case k >= reflect.Int && k <= reflect.Int64:
+		b.WriteString(strconv.FormatInt(v.Int(), 10))
+	case k >= reflect.Uint && k <= reflect.Uintptr:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case k == reflect.Float32 || k == reflect.Float64:
+		b.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	case k == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		quoteElem(b, `\x`+hex.EncodeToString(v.Bytes()))
+	default:
+		quoteElem(b, fmt.Sprint(v.Interface()))
+	}
+	return nil
+}
+
+// quoteElem writes the given string as a quoted array element.
+func quoteElem(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+}
@@ -0,0 +1,79 @@
+package duo
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArray_Scan(t *testing.T) {
+	var s Array[string]
+	require.NoError(t, s.Scan(`{a,"b c","d\"e",NULL,"NULL"}`))
+	assert.Equal(t, Array[string]{"a", "b c", `d"e`, "", "NULL"}, s)
+	require.NoError(t, s.Scan([]byte(`["x","y"]`)))
+	assert.Equal(t, Array[string]{"x", "y"}, s)
+	require.NoError(t, s.Scan(nil))
+	assert.Nil(t, s)
+	require.NoError(t, s.Scan("{}"))
+	assert.Equal(t, Array[string]{}, s)
+
+	var p Array[*int64]
+	require.NoError(t, p.Scan("{1,NULL,3}"))
+	require.Len(t, p, 3)
+	assert.Equal(t, int64(1), *p[0])
+	assert.Nil(t, p[1])
+
+	var n Array[int8]
+	assert.EqualError(t, n.Scan("{1,1000}"), `sql/array: element 1: strconv.ParseInt: parsing "1000": value out of range`)
+	assert.EqualError(t, n.Scan("{{1},{2}}"), "sql/array: multi-dimensional arrays are not supported")
+	assert.EqualError(t, n.Scan(`{"1}`), `sql/array: unterminated quoted element in "{\"1}"`)
+}
+
+func TestArray_Value(t *testing.T) {
+	v, err := Array[string]{"a", `b"c`, `d\e`}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, `{"a","b\"c","d\\e"}`, v)
+	v, err = Array[*int]{nil, new(int)}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "{NULL,0}", v)
+	v, err = Array[[]byte]{{0xde, 0xad}}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, `{"\\xdead"}`, v)
+	v, err = Array[int](nil).Value()
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	tags := Array[string]{"go", "sql"}
+	query, args := Dialect(Postgres).Select().From(Table("posts")).Where(EQ("tags", tags)).Query()
+	assert.Equal(t, `SELECT * FROM "posts" WHERE "tags" = $1`, query)
+	assert.Equal(t, []any{`{"go","sql"}`}, args)
+	query, args = Dialect(MySQL).Select().From(Table("posts")).Where(EQ("tags", tags)).Query()
+	assert.Equal(t, "SELECT * FROM `posts` WHERE `tags` = ?", query)
+	assert.Equal(t, []any{`["go","sql"]`}, args)
+}
+
+func TestScanSlice_Arrays(t *testing.T) {
+	type Post struct {
+		ID    int
+		Tags  []string
+		Votes []int64
+	}
+	mock := sqlmock.NewRows([]string{"id", "tags", "votes"}).
+		AddRow(1, `{go,sql}`, []byte(`{1,2}`)).
+		AddRow(2, `["a"]`, nil)
+	posts, err := ScanSlice[Post](toRows(mock))
+	require.NoError(t, err)
+	assert.Equal(t, []Post{{1, []string{"go", "sql"}, []int64{1, 2}}, {2, []string{"a"}, nil}}, posts)
+
+	tags, err := ScanSlice[[]string](toRows(sqlmock.NewRows([]string{"tags"}).AddRow("{a,b}")))
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}}, tags)
+
+	query, args := Dialect(Postgres).Insert("posts").SetStruct(posts[0]).Query()
+	assert.Equal(t, `INSERT INTO "posts" ("id", "tags", "votes") VALUES ($1, $2, $3)`, query)
+	assert.Equal(t, []any{1, `{"go","sql"}`, "{1,2}"}, args)
+	_, args = Dialect(SQLite).Insert("posts").SetStruct(posts[1]).Query()
+	assert.Equal(t, []any{2, `["a"]`, nil}, args)
+}
@@ -0,0 +1,164 @@
+package duo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Budget counts the statements executed through a Conn (or a Driver and its
+// transactions) for one unit of work, such as an HTTP request, and limits
+// their number. Statements are grouped by their fingerprint, which helps to
+// detect N+1 patterns, where the same query is executed in a loop.
+//
+//	ctx = duo.WithBudget(ctx, &duo.Budget{
+//		MaxQueries: 50,
+//		MaxRepeats: 10,
+//		OnExceed: func(err *duo.BudgetError) error {
+//			log.Println(err)
+//			return nil
+//		},
+//	})
+type Budget struct {
+	// MaxQueries is the maximum number of statements that can be
+	// executed with the budget. Zero means no limit.
+	MaxQueries int
+	// MaxRepeats is the maximum number of times a statement with the
+	// same fingerprint can be executed. Zero means no limit.
+	MaxRepeats int
+	// OnExceed is called once per violation (i.e. the first time a limit is
+	// exceeded for a fingerprint or for the total). The statement is executed
+	// if the callback returns nil, and fails with its error otherwise. If the
+	// callback is nil, all statements that exceed the budget fail with a
+	// *BudgetError.
+	OnExceed func(*BudgetError) error
+
+	mu     sync.Mutex
+	total  int
+	counts map[string]int
+}
+
+// BudgetError is returned (or passed to Budget.OnExceed)
+// when a statement exceeds the budget of the context.
+type BudgetError struct {
+	// Query is the statement that exceeded the budget.
+	Query string
+	// Fingerprint is the normalized form of the query.
+	Fingerprint string
+	// Repeats is the number of times the fingerprint was executed.
+	Repeats int
+	// Total is the total number of statements executed with the budget.
+	Total int
+	// Limit describes the exceeded limit. "max_queries" or "max_repeats".
+	Limit string
+}
+
+// Error implements the error interface.
+func (e *BudgetError) Error() string {
+	if e.Limit == "max_repeats" {
+		return fmt.Sprintf("sql/budget: statement executed %d times (possible N+1): %s", e.Repeats, e.Fingerprint)
+	}
+	return fmt.Sprintf("sql/budget: %d statements exceed the query budget: %s", e.Total, e.Fingerprint)
+}
+
+// Total returns the total number of statements counted by the budget.
+func (b *Budget) Total() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.total
+}
+
+// Counts returns the number of executions per statement fingerprint.
+func (b *Budget) Counts() map[string]int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	counts := make(map[string]int, len(b.counts))
+	for k, v := range b.counts {
+		counts[k] = v
+	}
+	return counts
+}
+
+// count records the execution of the given query,
+// and returns an error if it exceeds the budget.
+func (b *Budget) count(query string) error {
+	fp := Fingerprint(query)
+	b.mu.Lock()
+	if b.counts == nil {
+		b.counts = make(map[string]int)
+	}
+	b.total++
+	b.counts[fp]++
+	var (
+		err   *BudgetError
+		first bool
+		total = b.total
+		n     = b.counts[fp]
+	)
+	switch {
+	case b.MaxRepeats > 0 && n > b.MaxRepeats:
+		err, first = &BudgetError{Limit: "max_repeats"}, n == b.MaxRepeats+1
+	case b.MaxQueries > 0 && total > b.MaxQueries:
+		err, first = &BudgetError{Limit: "max_queries"}, total == b.MaxQueries+1
+	}
+	b.mu.Unlock()
+	if err == nil {
+		return nil
+	}
+	err.Query, err.Fingerprint, err.Repeats, err.Total = query, fp, n, total
+	switch {
+	case b.OnExceed == nil:
+		return err
+	case first:
+		return b.OnExceed(err)
+	}
+	return nil
+}
+
+// ctxBudgetKey is the context key for the query budget.
+type ctxBudgetKey struct{}
+
+// WithBudget returns a new context that carries the given budget. All statements
+// executed through a Conn with the returned context are counted by the budget.
+func WithBudget(ctx context.Context, b *Budget) context.Context {
+	return context.WithValue(ctx, ctxBudgetKey{}, b)
+}
+
+// BudgetFromContext returns the budget stored in the context, or nil if there is none.
+func BudgetFromContext(ctx context.Context) *Budget {
+	b, _ := ctx.Value(ctxBudgetKey{}).(*Budget)
+	return b
+}
+
+// budgetCheck counts the query in the budget of the context (if any).
+func budgetCheck(ctx context.Context, query string) error {
+	if b := BudgetFromContext(ctx); b != nil {
+		return b.count(query)
+	}
+	return nil
+}
+
+var (
+	fpString = regexp.MustCompile(`'(?:[^']|'')*'`)
+	fpParam  = regexp.MustCompile(`\$\d+\b|\b\d+(?:\.\d+)?\b`)
+	fpList   = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	fpTuples = regexp.MustCompile(`\(\?\)(?:\s*,\s*\(\?\))+`)
+	fpSpace  = regexp.MustCompile(`\s+`)
+)
+
+// Fingerprint returns a normalized form of the query, where literals and
+// placeholders are replaced with '?', and lists of values are collapsed.
+// Hence, queries that differ only in their arguments share a fingerprint.
+//
+//	Fingerprint("SELECT * FROM users WHERE id IN ($1, $2, $3)")
+//	// SELECT * FROM users WHERE id IN (?)
+func Fingerprint(query string) string {
+	query = fpString.ReplaceAllString(query, "?")
+	query = fpParam.ReplaceAllString(query, "?")
+	query = fpList.ReplaceAllString(query, "(?)")
+	query = fpTuples.ReplaceAllString(query, "(?)")
+	query = fpSpace.ReplaceAllString(query, " ")
+	return strings.TrimSpace(query)
+}
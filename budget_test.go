@@ -0,0 +1,63 @@
+package duo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	for q, fp := range map[string]string{
+		"SELECT * FROM `users` WHERE `id` = ?":                  "SELECT * FROM `users` WHERE `id` = ?",
+		"SELECT * FROM users WHERE id IN ($1, $2, $3)":          "SELECT * FROM users WHERE id IN (?)",
+		"SELECT * FROM t1 WHERE name = 'a''b' AND age > 10":     "SELECT * FROM t1 WHERE name = ? AND age > ?",
+		"INSERT INTO users (name, age) VALUES (?, ?), (?, ?)":   "INSERT INTO users (name, age) VALUES (?)",
+		"SELECT  *\n\tFROM users   WHERE id IN (1,2) LIMIT 1.5": "SELECT * FROM users WHERE id IN (?) LIMIT ?",
+	} {
+		assert.Equal(t, fp, Fingerprint(q))
+	}
+}
+
+func TestBudget(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	drv, err := OpenDB(Postgres, db)
+	require.NoError(t, err)
+
+	b := &Budget{MaxRepeats: 2}
+	ctx := WithBudget(context.Background(), b)
+	for i := 0; i < 2; i++ {
+		mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, drv.Exec(ctx, "UPDATE users SET age = $1 WHERE id = $2", []any{i, i}, nil))
+	}
+	err = drv.Exec(ctx, "UPDATE users SET age = $1 WHERE id = $2", []any{3, 3}, nil)
+	var berr *BudgetError
+	require.ErrorAs(t, err, &berr)
+	assert.Equal(t, "max_repeats", berr.Limit)
+	assert.Equal(t, 3, berr.Repeats)
+	assert.Equal(t, 3, b.Total())
+	assert.Equal(t, map[string]int{"UPDATE users SET age = ? WHERE id = ?": 3}, b.Counts())
+
+	var violations []*BudgetError
+	b = &Budget{
+		MaxQueries: 1,
+		OnExceed: func(err *BudgetError) error {
+			violations = append(violations, err)
+			return nil
+		},
+	}
+	ctx = WithBudget(context.Background(), b)
+	for i := 0; i < 3; i++ {
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		var rows Rows
+		require.NoError(t, drv.Query(ctx, "SELECT id FROM users", []any{}, &rows))
+		require.NoError(t, rows.Close())
+	}
+	require.Len(t, violations, 1)
+	assert.Equal(t, "max_queries", violations[0].Limit)
+	assert.Equal(t, 2, violations[0].Total)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
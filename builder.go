@@ -61,6 +61,25 @@ func (i *InsertBuilder) Set(column string, v interface{}) *InsertBuilder {
 	return i
 }
 
+// SetStruct is a syntactic sugar API for inserting one row from the fields of
+// a struct (or a pointer to a struct). Columns are named using the same tag rules
+// as the scanning functions, and fields tagged with the "json" option are encoded
+// as JSON documents. As in InsertStruct, fields tagged with the "omitempty" or the
+// "auto" options are skipped if they hold the zero value.
+//
+//	Insert("users").SetStruct(&User{Name: "a8m", Attrs: map[string]any{"a": 1}})
+func (i *InsertBuilder) SetStruct(v interface{}) *InsertBuilder {
+	columns, values, err := structArgs(v)
+	if err != nil {
+		i.AddError(err)
+		return i
+	}
+	for j := range columns {
+		i.Set(columns[j], values[j])
+	}
+	return i
+}
+
 // Columns appends columns to the INSERT statement.
 func (i *InsertBuilder) Columns(columns ...string) *InsertBuilder {
 	i.columns = append(i.columns, columns...)
@@ -438,6 +457,23 @@ func (u *UpdateBuilder) Set(column string, v interface{}) *UpdateBuilder {
 	return u
 }
 
+// SetStruct sets the columns of the statement from the fields of a struct (or a
+// pointer to a struct). Columns are named using the same tag rules as the scanning
+// functions, and fields tagged with the "json" option are encoded as JSON documents.
+// Fields tagged with the "omitempty" or the "auto" options are skipped if they hold
+// the zero value.
+func (u *UpdateBuilder) SetStruct(v interface{}) *UpdateBuilder {
+	columns, values, err := structArgs(v)
+	if err != nil {
+		u.AddError(err)
+		return u
+	}
+	for i := range columns {
+		u.Set(columns[i], values[i])
+	}
+	return u
+}
+
 // Add adds a numeric value to the given column. Note that, calling Set(c)
 // after Add(c) will erase previous calls with c from the builder.
 func (u *UpdateBuilder) Add(column string, v interface{}) *UpdateBuilder {
@@ -634,6 +670,21 @@ func (p *Predicate) False() *Predicate {
 	})
 }
 
+// True appends the TRUE keyword to the predicate.
+//
+//	Select().From(Table("users")).Where(True())
+//
+func True() *Predicate {
+	return P().True()
+}
+
+// True appends TRUE to the predicate.
+func (p *Predicate) True() *Predicate {
+	return p.Append(func(b *Builder) {
+		b.WriteString("TRUE")
+	})
+}
+
 // Not wraps the given predicate with the not predicate.
 //
 //	Not(Or(EQ("name", "foo"), EQ("name", "bar")))
@@ -2637,6 +2688,13 @@ type (
 		// from '?' to 'ST_GeomFromWKB(?)' for MySQL dialect.
 		FormatParam(placeholder string, info *StmtInfo) string
 	}
+	// ParamValuer wraps the ParamValue function.
+	ParamValuer interface {
+		// The ParamValue function lets users to define custom
+		// encoding of their types for the different dialects.
+		// For example, encoding arrays as JSON for MySQL.
+		ParamValue(info *StmtInfo) (driver.Value, error)
+	}
 )
 
 // Arg appends an input argument to the builder.
@@ -2649,8 +2707,17 @@ func (b *Builder) Arg(a interface{}) *Builder {
 		b.Join(a)
 		return b
 	}
+	v, err := convertArg(a)
+	if pv, ok := a.(ParamValuer); ok && err == nil {
+		if v, err = pv.ParamValue(&StmtInfo{Dialect: b.dialect}); err != nil {
+			err = fmt.Errorf("sql: encode argument of type %T: %w", a, err)
+		}
+	}
+	if err != nil {
+		b.AddError(err)
+	}
 	b.total++
-	b.args = append(b.args, a)
+	b.args = append(b.args, v)
 	// Default placeholder param (MySQL and SQLite).
 	param := "?"
 	if b.postgres() {
@@ -0,0 +1,160 @@
+package duo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ScanColumns scans all rows in columnar form into v, a pointer to a struct whose
+// fields are slices. Each column is appended to the slice field that is mapped to
+// it (using the same naming rules as ScanSlice), and its values are scanned into
+// the element type of the slice as struct fields of that type. Unknown columns are
+// collected by the CollectUnknown option into a field tagged with the "extra" option
+// of type map[string][]any. Use the Capacity option to pre-size the slices.
+// For example:
+//
+//	var series struct {
+//		Day   []time.Time
+//		Count []int64
+//	}
+//	// SELECT day, COUNT(*) AS count FROM events GROUP BY day
+//	err := duo.ScanColumns(rows, &series, duo.Capacity(365))
+func ScanColumns(rows ColumnScanner, v any, opts ...ScanOption) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("sql/scan: invalid type %T. expect a pointer to a struct", v)
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	cfg := newScanConfig(opts)
+	if cfg.types, err = columnTypes(rows); err != nil {
+		return err
+	}
+	var (
+		st    = rv.Elem()
+		scan  = &rowScan{}
+		extra []int
+		// collect holds the columns that are collected into the extra field.
+		collect map[string][]any
+		types   = strings.Split(cfg.types, "\x00")
+		names   = make(map[string]structField)
+		sets    = make([]func(reflect.Value, any) error, len(columns))
+		dsts    = make([]reflect.Value, len(columns))
+	)
+	for _, f := range structFields(st.Type(), cfg.naming) {
+		if f.opts.Has("extra") {
+			extra = f.index
+			if f.typ != extraColumnsType {
+				return fmt.Errorf("sql/scan: extra field %s must be of type map[string][]any", f.typ)
+			}
+			continue
+		}
+		names[cfg.naming.normalize(f.name)] = f
+	}
+	if cfg.unknown == unknownCollect && extra == nil {
+		return fmt.Errorf("sql/scan: missing extra field (`sql:\",extra\"`) in %s", st.Type())
+	}
+	for i, c := range columns {
+		name := cfg.naming.normalize(c)
+		f, ok := names[name]
+		if !ok {
+			if cfg.unknown == unknownFail {
+				return fmt.Errorf("sql/scan: missing struct field for column: %s (%s)", c, name)
+			}
+			scan.columns = append(scan.columns, anyType)
+			scan.fields = append(scan.fields, columnField{column: c})
+			if cfg.unknown == unknownCollect && collect == nil {
+				m := fieldByIndex(st, extra)
+				if m.IsNil() {
+					m.Set(reflect.MakeMap(extraColumnsType))
+				}
+				collect = m.Interface().(map[string][]any)
+			}
+			continue
+		}
+		if f.typ.Kind() != reflect.Slice {
+			return fmt.Errorf("sql/scan: invalid type %s for column %s. expect a slice", f.typ, c)
+		}
+		var dbType string
+		if i < len(types) {
+			dbType = types[i]
+		}
+		// Elements are scanned as the fields of their own type, with the tag options of the slice field.
+		ef := structField{name: f.name, typ: f.typ.Elem(), opts: f.opts}
+		rtype, set := scanField(ef.typ, ef, dbType, cfg.strictNull)
+		scan.columns = append(scan.columns, rtype)
+		scan.fields = append(scan.fields, columnField{column: c, path: fieldPath(st.Type(), f.index), typ: checkedType(ef, dbType)})
+		sets[i], dsts[i] = set, fieldByIndex(st, f.index)
+		if n := cfg.run.capacity; n > 0 && dsts[i].Cap()-dsts[i].Len() < n {
+			s := reflect.MakeSlice(f.typ, dsts[i].Len(), dsts[i].Len()+n)
+			reflect.Copy(s, dsts[i])
+			dsts[i].Set(s)
+		}
+	}
+	if err := validateColumns(rows, scan); err != nil {
+		return err
+	}
+	values := scan.values()
+	for row := 0; rows.Next(); row++ {
+		if err := rows.Scan(values...); err != nil {
+			return scanFailed(rows, scan, row, err, values...)
+		}
+		for i, set := range sets {
+			if set == nil {
+				// Unknown columns are either discarded or collected into the extra field.
+				if collect != nil {
+					collect[columns[i]] = append(collect[columns[i]], *values[i].(*any))
+				}
+				continue
+			}
+			d := dsts[i]
+			d.Set(reflect.Append(d, reflect.Zero(d.Type().Elem())))
+			if err := set(d.Index(d.Len()-1), values[i]); err != nil {
+				return scanFailed(rows, scan, row, fieldError(scan.fields[i], values[i], err))
+			}
+		}
+	}
+	return rows.Err()
+}
+
+var extraColumnsType = reflect.TypeOf(map[string][]any(nil))
+
+// ScanColumnsMap scans all rows in columnar form into a map from column names
+// to their values. Values are normalized as described in the Row type, and the
+// Capacity option can be used to pre-size the slices.
+func ScanColumnsMap(rows ColumnScanner, opts ...ScanOption) (map[string][]any, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		cfg    = newScanConfig(opts)
+		res    = make(map[string][]any, len(columns))
+		values = make([]any, len(columns))
+		dest   = make([]any, len(columns))
+	)
+	for i, c := range columns {
+		res[c] = make([]any, 0, cfg.run.capacity)
+		dest[i] = &values[i]
+	}
+	for row := 0; rows.Next(); row++ {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, scanFailed(rows, nil, row, err, dest...)
+		}
+		normalizeValues(types, values)
+		for i, c := range columns {
+			res[c] = append(res[c], values[i])
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,87 @@
+package duo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanColumns(t *testing.T) {
+	var (
+		day    = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		series struct {
+			Day   []time.Time
+			Count []int64
+			Label []*string
+		}
+	)
+	mock := sqlmock.NewRows([]string{"day", "COUNT(*)", "label"}).
+		AddRow(day, 10, "a").
+		AddRow(day.AddDate(0, 0, 1), 20, nil)
+	require.NoError(t, ScanColumns(toRows(mock), &series, Capacity(10)))
+	assert.Equal(t, []time.Time{day, day.AddDate(0, 0, 1)}, series.Day)
+	assert.Equal(t, []int64{10, 20}, series.Count)
+	assert.Equal(t, 10, cap(series.Count))
+	require.Len(t, series.Label, 2)
+	assert.Equal(t, "a", *series.Label[0])
+	assert.Nil(t, series.Label[1])
+
+	var invalid struct {
+		Count int
+	}
+	err := ScanColumns(toRows(sqlmock.NewRows([]string{"count"})), &invalid)
+	assert.EqualError(t, err, "sql/scan: invalid type int for column count. expect a slice")
+	err = ScanColumns(toRows(sqlmock.NewRows([]string{"name"})), &series)
+	assert.EqualError(t, err, "sql/scan: missing struct field for column: name (name)")
+	require.NoError(t, ScanColumns(toRows(sqlmock.NewRows([]string{"name"}).AddRow("a")), &series, IgnoreUnknown()))
+}
+
+func TestScanColumns_Unknown(t *testing.T) {
+	var series struct {
+		Count []int64
+		Extra map[string][]any `sql:",extra"`
+	}
+	mock := sqlmock.NewRows([]string{"count", "name"}).AddRow(1, "a").AddRow(2, nil)
+	require.NoError(t, ScanColumns(toRows(mock), &series, CollectUnknown()))
+	assert.Equal(t, []int64{1, 2}, series.Count)
+	assert.Equal(t, map[string][]any{"name": {"a", nil}}, series.Extra)
+
+	var missing struct {
+		Count []int64
+	}
+	err := ScanColumns(toRows(sqlmock.NewRows([]string{"count", "name"})), &missing, CollectUnknown())
+	assert.EqualError(t, err, "sql/scan: missing extra field (`sql:\",extra\"`) in struct { Count []int64 }")
+}
+
+func TestScanColumns_Errors(t *testing.T) {
+	type Series struct {
+		ID    []int
+		Count []int
+	}
+	var series Series
+	mock := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("INT", int64(0)),
+		sqlmock.NewColumn("count").OfType("DATETIME", time.Time{}),
+	).AddRow(1, time.Now())
+	err := ScanColumns(toRows(mock), &series)
+	assert.EqualError(t, err, `sql/scan: column "count" (DATETIME) into Series.Count: incompatible column type time.Time for int`)
+
+	mock = sqlmock.NewRows([]string{"id", "count"}).AddRow(1, 1).AddRow(2, nil)
+	err = ScanColumns(toRows(mock), &series, StrictNull())
+	assert.True(t, errors.Is(err, ErrUnexpectedNull))
+	assert.Contains(t, err.Error(), `sql/scan: column "count" (row 1) into Series.Count`)
+}
+
+func TestScanColumnsMap(t *testing.T) {
+	mock := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("name").OfType("VARCHAR", ""),
+		sqlmock.NewColumn("count").OfType("INT", 0),
+	).AddRow([]byte("a"), 1).AddRow([]byte("b"), nil)
+	cols, err := ScanColumnsMap(toRows(mock), Capacity(2))
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]any{"name": {"a", "b"}, "count": {int64(1), nil}}, cols)
+}
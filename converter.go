@@ -0,0 +1,214 @@
+package duo
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Converter converts values of type T from and to their database representation.
+// It allows scanning columns into types that do not implement sql.Scanner, and
+// passing values that do not implement driver.Valuer as arguments to the builders.
+//
+//	duo.RegisterConverter(duo.Converter[net.IP]{
+//		Scan: func(src any) (net.IP, error) {
+//			s, ok := src.(string)
+//			if !ok {
+//				s = string(src.([]byte))
+//			}
+//			return net.ParseIP(s), nil
+//		},
+//		Value: func(ip net.IP) (driver.Value, error) {
+//			return ip.String(), nil
+//		},
+//	})
+type Converter[T any] struct {
+	// Scan converts a non-NULL value returned by the driver to T.
+	// NULL values are not passed to Scan, and they leave the zero value.
+	Scan func(src any) (T, error)
+	// Value converts T to a value that is passed to the driver.
+	// If nil, values of type T are passed to the driver as is.
+	Value func(T) (driver.Value, error)
+}
+
+// converter is the type-erased form of Converter.
+type converter struct {
+	typ reflect.Type
+	// assign converts src and writes it to dst (a pointer to T).
+	assign func(dst unsafe.Pointer, src any) error
+	// value converts v (of type T) to a driver value.
+	value func(v any) (driver.Value, error)
+}
+
+// typeConverters holds the converters of a Go type.
+type typeConverters struct {
+	// all is used for columns of any database type.
+	all *converter
+	// columns holds the converters of specific database types.
+	columns map[string]*converter
+}
+
+var converters = struct {
+	sync.RWMutex
+	types map[reflect.Type]*typeConverters
+	// version is changed on every registration, and it is
+	// used to invalidate the cached rowScan of the types.
+	version uint64
+	// columns is set if there are converters that are
+	// registered for specific database types.
+	columns uint32
+}{types: make(map[reflect.Type]*typeConverters)}
+
+// RegisterConverter registers a converter for type T. Registered converters take
+// precedence over the default conversions of the scanning functions and of the
+// Builder.Arg method. If database type names are given (as reported by the
+// sql.ColumnType.DatabaseTypeName method), the converter is used for scanning
+// columns of these types only, and it takes precedence over the converter of T
+// that was registered without database types.
+func RegisterConverter[T any](c Converter[T], dbTypes ...string) {
+	conv := &converter{
+		typ: reflect.TypeOf((*T)(nil)).Elem(),
+		assign: func(dst unsafe.Pointer, src any) error {
+			v, err := c.Scan(src)
+			if err != nil {
+				return err
+			}
+			*(*T)(dst) = v
+			return nil
+		},
+	}
+	if c.Value != nil {
+		conv.value = func(v any) (driver.Value, error) {
+			return c.Value(v.(T))
+		}
+	}
+	converters.Lock()
+	defer converters.Unlock()
+	tc, ok := converters.types[conv.typ]
+	if !ok {
+		tc = &typeConverters{columns: make(map[string]*converter)}
+		converters.types[conv.typ] = tc
+	}
+	if len(dbTypes) == 0 {
+		tc.all = conv
+	}
+	for _, t := range dbTypes {
+		tc.columns[strings.ToUpper(t)] = conv
+		atomic.StoreUint32(&converters.columns, 1)
+	}
+	atomic.AddUint64(&converters.version, 1)
+}
+
+// convertersVersion returns the version of the converters registry.
+func convertersVersion() uint64 {
+	return atomic.LoadUint64(&converters.version)
+}
+
+// lookupConverter returns the converter of the given type for
+// columns of the given database type (that can be empty).
+func lookupConverter(typ reflect.Type, dbType string) *converter {
+	if convertersVersion() == 0 {
+		return nil
+	}
+	converters.RLock()
+	defer converters.RUnlock()
+	tc, ok := converters.types[typ]
+	if !ok {
+		return nil
+	}
+	if c, ok := tc.columns[strings.ToUpper(dbType)]; ok && dbType != "" {
+		return c
+	}
+	return tc.all
+}
+
+// ptrConverter returns the converter of the element type of the
+// given pointer type for columns of the given database type.
+func ptrConverter(typ reflect.Type, dbType string) *converter {
+	if typ.Kind() != reflect.Ptr {
+		return nil
+	}
+	return lookupConverter(typ.Elem(), dbType)
+}
+
+// unregisterConverter removes the converters of the given type.
+func unregisterConverter(typ reflect.Type) {
+	converters.Lock()
+	defer converters.Unlock()
+	delete(converters.types, typ)
+	var columns uint32
+	for _, tc := range converters.types {
+		if len(tc.columns) > 0 {
+			columns = 1
+		}
+	}
+	atomic.StoreUint32(&converters.columns, columns)
+	atomic.AddUint64(&converters.version, 1)
+}
+
+// hasConverter reports if there is a converter registered for the given type.
+func hasConverter(typ reflect.Type) bool {
+	if convertersVersion() == 0 {
+		return false
+	}
+	converters.RLock()
+	defer converters.RUnlock()
+	_, ok := converters.types[typ]
+	return ok
+}
+
+// convertArg converts the given argument using its registered converter (if any).
+func convertArg(a any) (any, error) {
+	if a == nil {
+		return nil, nil
+	}
+	c := lookupConverter(reflect.TypeOf(a), "")
+	if c == nil || c.value == nil {
+		return a, nil
+	}
+	v, err := c.value(a)
+	if err != nil {
+		return nil, fmt.Errorf("sql: convert argument of type %T: %w", a, err)
+	}
+	return v, nil
+}
+
+// columnTypes returns the database type names of the given rows joined by a
+// NUL character, if there are converters registered for specific database types.
+func columnTypes(rows ColumnScanner) (string, error) {
+	if atomic.LoadUint32(&converters.columns) == 0 {
+		return "", nil
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, len(types))
+	for i := range types {
+		names[i] = types[i].DatabaseTypeName()
+	}
+	return strings.Join(names, "\x00"), nil
+}
+
+// scanConverter returns the scan destination type and the assign function of a
+// column that is converted using the given converter. If strict is set, NULL
+// values fail with ErrUnexpectedNull instead of leaving the zero value.
+func scanConverter(c *converter, target func(reflect.Value) unsafe.Pointer, strict bool) (reflect.Type, func(reflect.Value, any) error) {
+	return anyType, func(st reflect.Value, v any) error {
+		src := *v.(*any)
+		if src == nil {
+			if strict {
+				return ErrUnexpectedNull
+			}
+			return nil
+		}
+		if err := c.assign(target(st), src); err != nil {
+			return fmt.Errorf("sql/scan: convert to %s: %w", c.typ, err)
+		}
+		return nil
+	}
+}
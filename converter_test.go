@@ -0,0 +1,96 @@
+package duo
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLevel int
+
+type testPoint struct{ X, Y int }
+
+// registerTestConverter registers the converter of T for the duration of the test.
+func registerTestConverter[T any](t *testing.T, c Converter[T], dbTypes ...string) {
+	RegisterConverter(c, dbTypes...)
+	t.Cleanup(func() {
+		unregisterConverter(reflect.TypeOf((*T)(nil)).Elem())
+	})
+}
+
+func TestRegisterConverter(t *testing.T) {
+	levels := []string{"low", "high"}
+	registerTestConverter(t, Converter[testLevel]{
+		Scan: func(src any) (testLevel, error) {
+			for i, l := range levels {
+				if fmt.Sprint(src) == l {
+					return testLevel(i), nil
+				}
+			}
+			return 0, fmt.Errorf("unknown level %v", src)
+		},
+		Value: func(l testLevel) (driver.Value, error) {
+			return levels[l], nil
+		},
+	})
+	registerTestConverter(t, Converter[testPoint]{
+		Scan: func(src any) (p testPoint, err error) {
+			_, err = fmt.Sscanf(fmt.Sprint(src), "(%d,%d)", &p.X, &p.Y)
+			return p, err
+		},
+	}, "POINT")
+	registerTestConverter(t, Converter[testPoint]{
+		Scan: func(src any) (p testPoint, err error) {
+			_, err = fmt.Sscanf(strings.ReplaceAll(fmt.Sprint(src), " ", ","), "%d,%d", &p.X, &p.Y)
+			return p, err
+		},
+	})
+
+	type Event struct {
+		Level testLevel
+		Point testPoint
+	}
+	mock := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("level").OfType("VARCHAR", ""),
+		sqlmock.NewColumn("point").OfType("POINT", ""),
+	).AddRow("high", "(1,2)").AddRow(nil, nil)
+	events, err := ScanSlice[Event](toRows(mock))
+	require.NoError(t, err)
+	assert.Equal(t, []Event{{Level: 1, Point: testPoint{1, 2}}, {}}, events)
+
+	mock = sqlmock.NewRowsWithColumnDefinition(sqlmock.NewColumn("point").OfType("TEXT", "")).AddRow("3 4")
+	points, err := ScanSlice[testPoint](toRows(mock))
+	require.NoError(t, err)
+	assert.Equal(t, []testPoint{{3, 4}}, points)
+
+	mock = sqlmock.NewRows([]string{"level"}).AddRow("medium")
+	_, err = ScanSlice[Event](toRows(mock))
+	assert.EqualError(t, err, `sql/scan: column "level" (row 0) into Event.Level: convert to duo.testLevel: unknown level medium (value: medium)`)
+
+	type Shape struct {
+		Level  *testLevel
+		Center *testPoint
+	}
+	mock = sqlmock.NewRows([]string{"level", "center"}).AddRow("low", "5 6").AddRow(nil, nil)
+	shapes, err := ScanSlice[Shape](toRows(mock))
+	require.NoError(t, err)
+	require.Len(t, shapes, 2)
+	require.NotNil(t, shapes[0].Level)
+	require.NotNil(t, shapes[0].Center)
+	assert.Equal(t, testLevel(0), *shapes[0].Level)
+	assert.Equal(t, testPoint{5, 6}, *shapes[0].Center)
+	assert.Equal(t, Shape{}, shapes[1])
+
+	_, err = ScanSlice[testPoint](toRows(sqlmock.NewRows(nil)))
+	assert.EqualError(t, err, "sql/scan: missing column for type duo.testPoint")
+
+	query, args := Dialect(Postgres).Select().From(Table("events")).Where(EQ("level", testLevel(1))).Query()
+	assert.Equal(t, `SELECT * FROM "events" WHERE "level" = $1`, query)
+	assert.Equal(t, []any{"high"}, args)
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"time"
 )
 
 type ExecContextQuery interface {
@@ -19,6 +20,44 @@ type ExecQuery interface {
 
 type Conn struct {
 	ExecContextQuery
+	// tracker records the Rows that were opened
+	// by the connection. Nil if tracking is disabled.
+	tracker *tracker
+	// guard detects concurrent use of a transaction.
+	// Nil if the connection is not a guarded Tx.
+	guard *txGuard
+}
+
+// ExecContext executes a statement on the underlying connection after
+// checking it against the query budget of the context (if any).
+func (c Conn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if err := budgetCheck(ctx, query); err != nil {
+		return nil, err
+	}
+	if c.guard != nil {
+		leave, err := c.guard.enter(query)
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+	}
+	return c.ExecContextQuery.ExecContext(ctx, query, args...)
+}
+
+// QueryContext executes a query on the underlying connection after
+// checking it against the query budget of the context (if any).
+func (c Conn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if err := budgetCheck(ctx, query); err != nil {
+		return nil, err
+	}
+	if c.guard != nil {
+		leave, err := c.guard.enter(query)
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+	}
+	return c.ExecContextQuery.QueryContext(ctx, query, args...)
 }
 
 func (c Conn) Exec(ctx context.Context, query string, args, v any) error {
@@ -60,24 +99,72 @@ func (c Conn) Query(ctx context.Context, query string, args, v any) error {
 	}
 
 	*vr = Rows{rows}
+	if c.guard != nil {
+		vr.ColumnScanner = c.guard.trackRows(vr.ColumnScanner, query)
+	}
+	if c.tracker != nil {
+		vr.ColumnScanner = c.tracker.trackRows(vr.ColumnScanner, query)
+	}
 	return nil
 }
 
 type Driver struct {
 	Conn
 	dialect string
+	// txGuard enables the concurrent-use guard on transactions.
+	txGuard bool
+}
+
+// Option configures the Driver returned by Open and OpenDB.
+type Option func(*Driver)
+
+// WithMaxOpenConns sets the maximum number of open connections to the database.
+func WithMaxOpenConns(n int) Option {
+	return func(d *Driver) {
+		d.DB().SetMaxOpenConns(n)
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of connections in the idle connection pool.
+func WithMaxIdleConns(n int) Option {
+	return func(d *Driver) {
+		d.DB().SetMaxIdleConns(n)
+	}
 }
 
-func Open(driver, source string) (*Driver, error) {
+// WithConnMaxLifetime sets the maximum amount of time a connection may be reused.
+func WithConnMaxLifetime(t time.Duration) Option {
+	return func(d *Driver) {
+		d.DB().SetConnMaxLifetime(t)
+	}
+}
+
+// WithConnMaxIdleTime sets the maximum amount of time a connection may be idle.
+func WithConnMaxIdleTime(t time.Duration) Option {
+	return func(d *Driver) {
+		d.DB().SetConnMaxIdleTime(t)
+	}
+}
+
+func Open(driver, source string, opts ...Option) (*Driver, error) {
 	db, err := sql.Open(driver, source)
 	if err != nil {
 		return nil, err
 	}
-	return &Driver{Conn{db}, driver}, nil
+	return OpenDB(driver, db, opts...)
+}
+
+func OpenDB(driver string, db *sql.DB, opts ...Option) (*Driver, error) {
+	drv := &Driver{Conn: Conn{ExecContextQuery: db}, dialect: driver}
+	for _, opt := range opts {
+		opt(drv)
+	}
+	return drv, nil
 }
 
-func OpenDB(driver string, db *sql.DB) (*Driver, error) {
-	return &Driver{Conn{db}, driver}, nil
+// Dialect returns the dialect name of the driver.
+func (d Driver) Dialect() string {
+	return d.dialect
 }
 
 func (d Driver) DB() *sql.DB {
@@ -87,6 +174,40 @@ func (d Driver) DB() *sql.DB {
 type Tx struct {
 	ExecContextQuery
 	driver.Tx
+	// tracker and guard are set on the transaction
+	// connection by the statements of the Tx.
+	tracker *tracker
+	guard   *txGuard
+}
+
+// ExecContext executes a statement in the transaction.
+func (t *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return t.conn().ExecContext(ctx, query, args...)
+}
+
+// QueryContext executes a query in the transaction.
+func (t *Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return t.conn().QueryContext(ctx, query, args...)
+}
+
+// Exec executes a statement in the transaction. See Conn.Exec for details.
+func (t *Tx) Exec(ctx context.Context, query string, args, v any) error {
+	return t.conn().Exec(ctx, query, args, v)
+}
+
+// Query executes a query in the transaction. See Conn.Query for details.
+func (t *Tx) Query(ctx context.Context, query string, args, v any) error {
+	return t.conn().Query(ctx, query, args, v)
+}
+
+// conn returns the connection of the transaction.
+func (t *Tx) conn() Conn {
+	c, ok := t.ExecContextQuery.(Conn)
+	if !ok {
+		c = Conn{ExecContextQuery: t.ExecContextQuery}
+	}
+	c.tracker, c.guard = t.tracker, t.guard
+	return c
 }
 
 func (d *Driver) Tx(ctx context.Context) (*Tx, error) {
@@ -98,10 +219,18 @@ func (d *Driver) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error)
 	if err != nil {
 		return nil, err
 	}
-	return &Tx{
-		ExecContextQuery: Conn{tx},
+	t := &Tx{
+		ExecContextQuery: Conn{ExecContextQuery: tx},
 		Tx:               tx,
-	}, nil
+		tracker:          d.tracker,
+	}
+	if d.txGuard {
+		t.guard = &txGuard{dialect: d.dialect}
+	}
+	if d.tracker != nil {
+		t.Tx = d.tracker.trackTx(tx)
+	}
+	return t, nil
 }
 
 func (d *Driver) Close() error {
@@ -0,0 +1,273 @@
+package duo
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// exportConfig holds the configuration of the export functions.
+	exportConfig struct {
+		timeFormat string
+		null       string
+		comma      rune
+		noHeader   bool
+	}
+
+	// ExportOption allows configuring the export
+	// functions using functional options.
+	ExportOption func(*exportConfig)
+)
+
+// WithTimeFormat sets the layout of time values. Defaults to time.RFC3339Nano.
+// Columns of type DATE are always formatted as "2006-01-02".
+func WithTimeFormat(layout string) ExportOption {
+	return func(c *exportConfig) {
+		c.timeFormat = layout
+	}
+}
+
+// WithNullString sets the CSV representation of NULL values. Defaults to
+// an empty string. In JSON formats, NULL values are written as null.
+func WithNullString(s string) ExportOption {
+	return func(c *exportConfig) {
+		c.null = s
+	}
+}
+
+// WithComma sets the CSV field delimiter. Defaults to ','.
+func WithComma(r rune) ExportOption {
+	return func(c *exportConfig) {
+		c.comma = r
+	}
+}
+
+// WithoutHeader omits the CSV header row.
+func WithoutHeader() ExportOption {
+	return func(c *exportConfig) {
+		c.noHeader = true
+	}
+}
+
+func newExportConfig(opts []ExportOption) *exportConfig {
+	cfg := &exportConfig{timeFormat: time.RFC3339Nano, comma: ','}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WriteCSV streams the rows to w in CSV format, with a header row that holds
+// the column names. Values are formatted using the database type of their
+// columns: times are formatted using the time format, binary values are
+// encoded as base64, and NULL values are written as the NULL string.
+//
+//	rows, err := db.QueryContext(ctx, query, args...)
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//	return duo.WriteCSV(w, rows)
+func WriteCSV(w io.Writer, rows ColumnScanner, opts ...ExportOption) error {
+	cfg := newExportConfig(opts)
+	cw := csv.NewWriter(w)
+	cw.Comma = cfg.comma
+	err := exportRows(rows, cfg, func(columns []string) error {
+		if cfg.noHeader {
+			return nil
+		}
+		return cw.Write(columns)
+	}, func(_ []string, values []any) error {
+		record := make([]string, len(values))
+		for i, v := range values {
+			record[i] = csvValue(v, cfg)
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSONLines streams the rows to w in JSON Lines format, one JSON object per
+// row. The object keys are the column names, and they are written in the order
+// of the columns. Values are formatted as in WriteCSV, except that NULL values
+// are written as null, and numeric and boolean values are written as is.
+func WriteJSONLines(w io.Writer, rows ColumnScanner, opts ...ExportOption) error {
+	bw := bufio.NewWriter(w)
+	err := exportRows(rows, newExportConfig(opts), nil, func(columns []string, values []any) error {
+		if err := writeObject(bw, columns, values); err != nil {
+			return err
+		}
+		return bw.WriteByte('\n')
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// WriteJSON streams the rows to w as a JSON array of objects.
+// Objects are formatted as in WriteJSONLines.
+func WriteJSON(w io.Writer, rows ColumnScanner, opts ...ExportOption) error {
+	var (
+		n  int
+		bw = bufio.NewWriter(w)
+	)
+	if err := bw.WriteByte('['); err != nil {
+		return err
+	}
+	err := exportRows(rows, newExportConfig(opts), nil, func(columns []string, values []any) error {
+		if n++; n > 1 {
+			if err := bw.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		return writeObject(bw, columns, values)
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := bw.WriteString("]\n"); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// exportRows scans the rows one by one, and calls the given functions with
+// the column names, and with the exported values of each row.
+func exportRows(rows ColumnScanner, cfg *exportConfig, header func([]string) error, row func([]string, []any) error) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	if header != nil {
+		if err := header(columns); err != nil {
+			return err
+		}
+	}
+	var (
+		names  = make([]string, len(columns))
+		values = make([]any, len(columns))
+		dest   = make([]any, len(columns))
+	)
+	for i := range columns {
+		dest[i] = &values[i]
+		if i < len(types) {
+			names[i] = strings.ToUpper(types[i].DatabaseTypeName())
+		}
+	}
+	for n := 0; rows.Next(); n++ {
+		if err := rows.Scan(dest...); err != nil {
+			return scanFailed(rows, nil, n, err, dest...)
+		}
+		normalizeValues(types, values)
+		for i, v := range values {
+			values[i] = exportValue(v, names[i], cfg)
+		}
+		if err := row(columns, values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// exportValue formats the given value using the database type of its column.
+// The returned value is either nil, a string, a json.Number, a bool, an int64
+// or a float64.
+func exportValue(v any, dbType string, cfg *exportConfig) any {
+	switch v := v.(type) {
+	case nil, bool, int64, float64:
+		return v
+	case time.Time:
+		if dbType == "DATE" {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(cfg.timeFormat)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case string:
+		// Numeric values are returned by some drivers as text (e.g. MySQL without
+		// parseTime or prepared statements), and they are exported as numbers
+		// as-is to keep their precision.
+		if numericType(dbType) && jsonNumber(v) {
+			return json.Number(v)
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// numericType reports if the given database type name is an integer,
+// a floating-point or an exact numeric type.
+func numericType(name string) bool {
+	name = strings.TrimSpace(strings.TrimPrefix(strings.ToUpper(name), "UNSIGNED "))
+	switch name {
+	case "INT", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT", "INT2", "INT4", "INT8",
+		"FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "DOUBLE PRECISION", "REAL", "DECIMAL", "NUMERIC", "YEAR":
+		return true
+	}
+	return false
+}
+
+// jsonNumber reports if the given string is a valid JSON number. NaN and
+// infinity values of floating-point columns are kept as strings.
+func jsonNumber(s string) bool {
+	return s != "" && (s[0] == '-' || s[0] >= '0' && s[0] <= '9') && json.Valid([]byte(s))
+}
+
+// csvValue returns the CSV field of the given exported value.
+func csvValue(v any, cfg *exportConfig) string {
+	switch v := v.(type) {
+	case nil:
+		return cfg.null
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// writeObject writes the row as a JSON object with ordered keys.
+func writeObject(w *bufio.Writer, columns []string, values []any) error {
+	w.WriteByte('{')
+	for i, c := range columns {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		k, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		v, err := json.Marshal(values[i])
+		if err != nil {
+			return fmt.Errorf("sql/export: marshal column %q: %w", c, err)
+		}
+		w.Write(k)
+		w.WriteByte(':')
+		w.Write(v)
+	}
+	return w.WriteByte('}')
+}
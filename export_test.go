@@ -0,0 +1,71 @@
+package duo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportRowsMock() *sqlmock.Rows {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	return sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("INT", 0),
+		sqlmock.NewColumn("name").OfType("VARCHAR", ""),
+		sqlmock.NewColumn("price").OfType("DECIMAL", ""),
+		sqlmock.NewColumn("day").OfType("DATE", time.Time{}),
+		sqlmock.NewColumn("created").OfType("TIMESTAMP", time.Time{}),
+		sqlmock.NewColumn("data").OfType("BLOB", []byte{}),
+		sqlmock.NewColumn("active").OfType("BOOL", false),
+	).
+		AddRow(1, []byte("a,b"), []byte("1.50"), ts, ts, []byte{1, 2}, true).
+		AddRow(2, nil, nil, nil, nil, nil, nil)
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b strings.Builder
+	require.NoError(t, WriteCSV(&b, toRows(exportRowsMock()), WithNullString(`\N`)))
+	assert.Equal(t, "id,name,price,day,created,data,active\n"+
+		`1,"a,b",1.50,2020-01-02,2020-01-02T03:04:05Z,AQI=,true`+"\n"+
+		`2,\N,\N,\N,\N,\N,\N`+"\n", b.String())
+
+	b.Reset()
+	require.NoError(t, WriteCSV(&b, toRows(exportRowsMock()), WithoutHeader(), WithComma(';'), WithTimeFormat(time.Kitchen)))
+	assert.Equal(t, "1;a,b;1.50;2020-01-02;3:04AM;AQI=;true\n2;;;;;;\n", b.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+	var b strings.Builder
+	require.NoError(t, WriteJSONLines(&b, toRows(exportRowsMock())))
+	assert.Equal(t, `{"id":1,"name":"a,b","price":1.50,"day":"2020-01-02","created":"2020-01-02T03:04:05Z","data":"AQI=","active":true}`+"\n"+
+		`{"id":2,"name":null,"price":null,"day":null,"created":null,"data":null,"active":null}`+"\n", b.String())
+
+	b.Reset()
+	require.NoError(t, WriteJSON(&b, toRows(exportRowsMock())))
+	assert.Equal(t, `[{"id":1,"name":"a,b","price":1.50,"day":"2020-01-02","created":"2020-01-02T03:04:05Z","data":"AQI=","active":true},`+
+		`{"id":2,"name":null,"price":null,"day":null,"created":null,"data":null,"active":null}]`+"\n", b.String())
+
+	b.Reset()
+	require.NoError(t, WriteJSON(&b, toRows(sqlmock.NewRows([]string{"id"}))))
+	assert.Equal(t, "[]\n", b.String())
+}
+
+func TestWriteJSON_TextNumbers(t *testing.T) {
+	// MySQL returns numeric columns as text, unless prepared statements are used.
+	mock := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("INT", []byte{}),
+		sqlmock.NewColumn("views").OfType("UNSIGNED BIGINT", []byte{}),
+		sqlmock.NewColumn("score").OfType("DOUBLE", []byte{}),
+		sqlmock.NewColumn("ratio").OfType("FLOAT", []byte{}),
+		sqlmock.NewColumn("code").OfType("VARCHAR", []byte{}),
+	).
+		AddRow([]byte("1"), []byte("18446744073709551615"), []byte("1.5e-7"), []byte("NaN"), []byte("007")).
+		AddRow([]byte("-2"), nil, []byte("0"), []byte("2.25"), nil)
+	var b strings.Builder
+	require.NoError(t, WriteJSONLines(&b, toRows(mock)))
+	assert.Equal(t, `{"id":1,"views":18446744073709551615,"score":1.5e-7,"ratio":"NaN","code":"007"}`+"\n"+
+		`{"id":-2,"views":null,"score":0,"ratio":2.25,"code":null}`+"\n", b.String())
+}
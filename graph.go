@@ -0,0 +1,313 @@
+package duo
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ScanGraph scans the rows of a one-to-many join into a slice of T, where rows
+// that share the same key are folded into a single value, and the columns of the
+// joined tables are collected into slice-of-struct fields. Fields of the child
+// structs are mapped to columns prefixed with the "prefix" option of the slice
+// field, or with its column name followed by a dot. The key of each level is
+// defined by the fields tagged with the "pk" option (or by its "id" field),
+// and values are returned in the order they first appear in the result set.
+// Children with NULL keys (unmatched LEFT JOINs) are skipped. For example:
+//
+//	type Post struct {
+//		ID    int `sql:",pk"`
+//		Title string
+//	}
+//
+//	type User struct {
+//		ID    int    `sql:",pk"`
+//		Name  string
+//		Posts []Post `sql:",prefix=post_"`
+//	}
+//
+//	// SELECT users.id, users.name, posts.id AS post_id, posts.title AS post_title
+//	// FROM users LEFT JOIN posts ON posts.user_id = users.id
+//	users, err := duo.ScanGraph[User](rows)
+//
+// Children can be nested in multiple levels, and the prefixes of nested levels
+// are appended to the prefixes of their parents.
+func ScanGraph[T any](rows ColumnScanner, opts ...ScanOption) ([]T, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	cfg := newScanConfig(opts)
+	if cfg.types, err = columnTypes(rows); err != nil {
+		return nil, err
+	}
+	var (
+		res []T
+		typ = reflect.TypeOf((*T)(nil)).Elem()
+	)
+	root, err := graphType(typ, "", cfg.naming)
+	if err != nil {
+		return nil, err
+	}
+	values, err := root.compile(columns, cfg)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		set = newGraphSet()
+		dst = reflect.ValueOf(&res).Elem()
+	)
+	for i := 0; rows.Next(); i++ {
+		if err := rows.Scan(values...); err != nil {
+			return nil, scanFailed(rows, nil, i, err, values...)
+		}
+		if err := root.fold(set, dst, values); err != nil {
+			return nil, scanFailed(rows, nil, i, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// graphNode is a level in the graph of ScanGraph.
+type graphNode struct {
+	// typ of the slice elements, and the struct type of the node.
+	typ, st reflect.Type
+	// prefix of the node columns.
+	prefix string
+	// index of the slice field in the parent struct.
+	index []int
+	// fields of the node, keyed by their normalized column names.
+	fields map[string]structField
+	// keys are the names of the key fields.
+	keys     []string
+	children []*graphNode
+	// compiled state.
+	scan *rowScan
+	// cols and keyCols hold the positions of the node columns and of its
+	// key columns in the row.
+	cols, keyCols []int
+}
+
+// graphType returns the graphNode of the given slice element type.
+func graphType(typ reflect.Type, prefix string, n *NamingStrategy) (*graphNode, error) {
+	node := &graphNode{typ: typ, st: indirect(typ), prefix: prefix, fields: make(map[string]structField)}
+	if node.st.Kind() != reflect.Struct || scannable(node.st) {
+		return nil, fmt.Errorf("sql/scan: invalid graph type %s. expect a struct or a pointer to a struct", typ)
+	}
+	var own []structField
+	for _, f := range structFields(node.st, n) {
+		if f.typ.Kind() == reflect.Slice {
+			if et := indirect(f.typ.Elem()); et.Kind() == reflect.Struct && !scannable(et) && !f.opts.Has("json") && !hasConverter(f.typ) {
+				p, ok := f.opts.Value("prefix")
+				if !ok {
+					p = f.name + "."
+				}
+				child, err := graphType(f.typ.Elem(), prefix+p, n)
+				if err != nil {
+					return nil, err
+				}
+				child.index = f.index
+				node.children = append(node.children, child)
+				continue
+			}
+		}
+		node.fields[n.normalize(f.name)] = f
+		own = append(own, f)
+	}
+	for _, f := range primaryKey(own, n) {
+		node.keys = append(node.keys, n.normalize(f.name))
+	}
+	if len(node.keys) == 0 {
+		return nil, fmt.Errorf("sql/scan: missing key field (`sql:\",pk\"`) in %s", node.st)
+	}
+	return node, nil
+}
+
+// nodes returns the node and all its descendants.
+func (g *graphNode) nodes() []*graphNode {
+	nodes := []*graphNode{g}
+	for _, c := range g.children {
+		nodes = append(nodes, c.nodes()...)
+	}
+	return nodes
+}
+
+// compile assigns the columns to the nodes of the graph, compiles their rowScan,
+// and returns the scan destinations of a row. A column is assigned to the node
+// with the longest prefix that has a field for it.
+func (g *graphNode) compile(columns []string, cfg scanConfig) ([]any, error) {
+	var (
+		nodes  = g.nodes()
+		values = make([]any, len(columns))
+		names  = make([][]string, len(nodes))
+		types  = make([][]string, len(nodes))
+		all    = strings.Split(cfg.types, "\x00")
+	)
+	for i, c := range columns {
+		name, owner := cfg.naming.normalize(c), -1
+		for j, node := range nodes {
+			p := cfg.naming.normalize(node.prefix)
+			if !strings.HasPrefix(name, p) {
+				continue
+			}
+			if _, ok := node.fields[name[len(p):]]; ok && (owner == -1 || len(node.prefix) > len(nodes[owner].prefix)) {
+				owner = j
+			}
+		}
+		if owner == -1 {
+			if cfg.unknown != unknownIgnore {
+				return nil, fmt.Errorf("sql/scan: missing struct field for column: %s (%s)", c, name)
+			}
+			values[i] = new(any)
+			continue
+		}
+		node := nodes[owner]
+		node.cols = append(node.cols, i)
+		names[owner] = append(names[owner], node.fields[name[len(cfg.naming.normalize(node.prefix)):]].name)
+		if i < len(all) {
+			types[owner] = append(types[owner], all[i])
+		}
+	}
+	for j, node := range nodes {
+		// Children that are not selected by the query are left empty.
+		if j > 0 && len(names[j]) == 0 {
+			continue
+		}
+		for _, k := range node.keys {
+			pos := -1
+			for x, c := range names[j] {
+				if cfg.naming.normalize(c) == k {
+					pos = x
+				}
+			}
+			if pos == -1 {
+				return nil, fmt.Errorf("sql/scan: missing key column %q of %s", node.prefix+k, node.st)
+			}
+			node.keyCols = append(node.keyCols, node.cols[pos])
+		}
+		ncfg := cfg
+		ncfg.unknown, ncfg.types = unknownFail, strings.Join(types[j], "\x00")
+		scan, err := scanType(node.typ, names[j], ncfg)
+		if err != nil {
+			return nil, err
+		}
+		node.scan = scan
+		for x, v := range scan.values() {
+			values[node.cols[x]] = v
+		}
+	}
+	return values, nil
+}
+
+// graphSet holds the values of a node that were already added to a slice.
+type graphSet struct {
+	index map[any]int
+	// children holds the sets of the children of each value.
+	children [][]*graphSet
+}
+
+func newGraphSet() *graphSet {
+	return &graphSet{index: make(map[any]int)}
+}
+
+// fold folds the row values into the given slice. A value is appended to the
+// slice if its key was not seen before, and its children are folded into it.
+func (g *graphNode) fold(set *graphSet, slice reflect.Value, values []any) error {
+	if g.scan == nil {
+		return nil
+	}
+	vs := make([]any, len(g.cols))
+	for x, c := range g.cols {
+		vs[x] = values[c]
+	}
+	key, ok := g.key(values)
+	if !ok {
+		g.scan.reset(vs)
+		return nil
+	}
+	i, ok := set.index[key]
+	if ok {
+		// Values that were already added are not assigned again,
+		// and their holders are reset before the next row.
+		g.scan.reset(vs)
+	} else {
+		i = slice.Len()
+		slice.Set(reflect.Append(slice, reflect.Zero(g.typ)))
+		if err := g.scan.assign(slice.Index(i), vs...); err != nil {
+			return err
+		}
+		set.index[key] = i
+		children := make([]*graphSet, len(g.children))
+		for x := range g.children {
+			children[x] = newGraphSet()
+		}
+		set.children = append(set.children, children)
+	}
+	elem := reflect.Indirect(slice.Index(i))
+	for x, c := range g.children {
+		if err := c.fold(set.children[i][x], fieldByIndex(elem, c.index), values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// key returns the key of the node in the given row values,
+// and reports false if all of its key columns are NULL.
+func (g *graphNode) key(values []any) (any, bool) {
+	if len(g.keyCols) == 1 {
+		return scannedValue(values[g.keyCols[0]])
+	}
+	var (
+		valid bool
+		key   = reflect.New(reflect.ArrayOf(len(g.keyCols), anyType)).Elem()
+	)
+	for i, c := range g.keyCols {
+		if v, ok := scannedValue(values[c]); ok {
+			key.Index(i).Set(reflect.ValueOf(v))
+			valid = true
+		}
+	}
+	return key.Interface(), valid
+}
+
+// scannedValue returns a comparable copy of the value held by the given
+// scan destination, and reports false if the scanned value is NULL.
+func scannedValue(dest any) (any, bool) {
+	var v any
+	if dest == nil {
+		return nil, false
+	}
+	if vr, ok := dest.(driver.Valuer); ok {
+		v, _ = vr.Value()
+	} else {
+		rv := reflect.ValueOf(dest).Elem()
+		for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+			if rv.IsNil() {
+				return nil, false
+			}
+			rv = rv.Elem()
+		}
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			if rv.IsNil() {
+				return nil, false
+			}
+			return string(rv.Bytes()), true
+		}
+		v = rv.Interface()
+	}
+	switch b := v.(type) {
+	case nil:
+		return nil, false
+	case []byte:
+		return string(b), b != nil
+	}
+	if !reflect.TypeOf(v).Comparable() {
+		return fmt.Sprint(v), true
+	}
+	return v, true
+}
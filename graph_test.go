@@ -0,0 +1,119 @@
+package duo
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanGraph(t *testing.T) {
+	type Comment struct {
+		ID   int `sql:",pk"`
+		Text string
+	}
+	type Post struct {
+		ID       int `sql:",pk"`
+		Title    string
+		Comments []*Comment `sql:",prefix=comment_"`
+	}
+	type User struct {
+		ID    int
+		Name  string
+		Posts []Post `sql:",prefix=post_"`
+	}
+	rows := toRows(sqlmock.NewRows([]string{"id", "name", "post_id", "post_title", "post_comment_id", "post_comment_text"}).
+		AddRow(2, "bar", 10, "a", 100, "x").
+		AddRow(2, "bar", 10, "a", 101, "y").
+		AddRow(1, "foo", nil, nil, nil, nil).
+		AddRow(2, "bar", 11, "b", nil, nil).
+		AddRow(2, "bar", 10, "a", 100, "x"))
+	users, err := ScanGraph[User](rows)
+	require.NoError(t, err)
+	assert.Equal(t, []User{
+		{
+			ID:   2,
+			Name: "bar",
+			Posts: []Post{
+				{ID: 10, Title: "a", Comments: []*Comment{{100, "x"}, {101, "y"}}},
+				{ID: 11, Title: "b"},
+			},
+		},
+		{ID: 1, Name: "foo"},
+	}, users)
+
+	rows = toRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo"))
+	users, err = ScanGraph[User](rows)
+	require.NoError(t, err)
+	assert.Equal(t, []User{{ID: 1, Name: "foo"}}, users)
+
+	rows = toRows(sqlmock.NewRows([]string{"name", "post_id"}).AddRow("foo", 1))
+	_, err = ScanGraph[User](rows)
+	assert.EqualError(t, err, `sql/scan: missing key column "id" of duo.User`)
+
+	type Group struct {
+		Name  string
+		Users []User
+	}
+	_, err = ScanGraph[Group](toRows(sqlmock.NewRows([]string{"name"})))
+	assert.EqualError(t, err, "sql/scan: missing key field (`sql:\",pk\"`) in duo.Group")
+}
+
+func TestScanGraph_CompositeKey(t *testing.T) {
+	type Item struct {
+		Product string
+		Qty     int
+	}
+	type Line struct {
+		OrderID int    `sql:"order_id,pk"`
+		LineNo  int    `sql:"line_no,pk"`
+		Items   []Item `sql:"items"`
+	}
+	_, err := ScanGraph[Line](toRows(sqlmock.NewRows([]string{"order_id"})))
+	assert.EqualError(t, err, "sql/scan: missing key field (`sql:\",pk\"`) in duo.Item")
+
+	type Tag struct {
+		ID   int
+		Name string
+	}
+	type Entry struct {
+		OrderID int   `sql:"order_id,pk"`
+		LineNo  int   `sql:"line_no,pk"`
+		Tags    []Tag `sql:"tags"`
+	}
+	rows := toRows(sqlmock.NewRows([]string{"order_id", "line_no", "tags.id", "tags.name"}).
+		AddRow(1, 1, 1, "a").
+		AddRow(1, 2, 1, "a").
+		AddRow(1, 1, 2, "b"))
+	entries, err := ScanGraph[Entry](rows)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{OrderID: 1, LineNo: 1, Tags: []Tag{{1, "a"}, {2, "b"}}},
+		{OrderID: 1, LineNo: 2, Tags: []Tag{{1, "a"}}},
+	}, entries)
+}
+
+func TestScanGraph_ScannerHolders(t *testing.T) {
+	type Post struct {
+		ID    int       `sql:",pk"`
+		Attrs testAttrs `sql:"attrs"`
+	}
+	type User struct {
+		ID    int       `sql:",pk"`
+		Attrs testAttrs `sql:"attrs"`
+		Posts []Post    `sql:",prefix=post_"`
+	}
+	rows := toRows(sqlmock.NewRows([]string{"id", "attrs", "post_id", "post_attrs"}).
+		AddRow(1, `{"a":1}`, 1, `{"x":1}`).
+		AddRow(1, `{"a":1}`, 1, `{"x":1}`).
+		AddRow(2, `{"b":2}`, nil, nil).
+		AddRow(3, nil, 2, `{"y":2}`))
+	users, err := ScanGraph[User](rows)
+	require.NoError(t, err)
+	assert.Equal(t, []User{
+		{ID: 1, Attrs: testAttrs{"a": 1.0}, Posts: []Post{{ID: 1, Attrs: testAttrs{"x": 1.0}}}},
+		{ID: 2, Attrs: testAttrs{"b": 2.0}},
+		{ID: 3, Posts: []Post{{ID: 2, Attrs: testAttrs{"y": 2.0}}}},
+	}, users)
+}
@@ -0,0 +1,147 @@
+package duo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// HealthReport describes the state of the database
+// connection as observed by Driver.Health.
+type HealthReport struct {
+	// Ping is the round-trip time of the ping.
+	Ping time.Duration
+	// Query is the round-trip time of a trivial query (SELECT 1).
+	Query time.Duration
+	// ReplicationLag is the replication delay of the database. It is nil
+	// in case it was not requested, or the database is not a replica.
+	ReplicationLag *time.Duration
+	// Stats holds the connection pool statistics.
+	Stats sql.DBStats
+}
+
+type (
+	// healthConfig holds the configuration of the health check.
+	healthConfig struct {
+		lag bool
+	}
+
+	// HealthOption allows configuring the health
+	// check using functional options.
+	HealthOption func(*healthConfig)
+)
+
+// WithReplicationLag measures the replication lag as part of the health check.
+// Supported by PostgreSQL (pg_last_xact_replay_timestamp) and MySQL (Seconds_Behind_Source).
+// For other dialects, the lag is not reported.
+func WithReplicationLag() HealthOption {
+	return func(c *healthConfig) {
+		c.lag = true
+	}
+}
+
+// Health pings the database, runs a trivial query and optionally measures the
+// replication lag. The returned report is filled with all information collected
+// until the first failure, and it always contains the pool statistics.
+//
+//	report, err := drv.Health(ctx, WithReplicationLag())
+//	if err != nil {
+//		return err
+//	}
+//	log.Println(report.Ping, report.Stats.InUse)
+func (d *Driver) Health(ctx context.Context, opts ...HealthOption) (*HealthReport, error) {
+	cfg := &healthConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	report := &HealthReport{}
+	defer func() {
+		report.Stats = d.DB().Stats()
+	}()
+	start := time.Now()
+	if err := d.DB().PingContext(ctx); err != nil {
+		return report, fmt.Errorf("sql/health: ping: %w", err)
+	}
+	report.Ping = time.Since(start)
+	start = time.Now()
+	var one int
+	if err := d.DB().QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return report, fmt.Errorf("sql/health: query: %w", err)
+	}
+	report.Query = time.Since(start)
+	if cfg.lag {
+		lag, err := d.replicationLag(ctx)
+		if err != nil {
+			return report, fmt.Errorf("sql/health: replication lag: %w", err)
+		}
+		report.ReplicationLag = lag
+	}
+	return report, nil
+}
+
+// replicationLag returns the replication lag of the database,
+// or nil if the database is not a replica.
+func (d *Driver) replicationLag(ctx context.Context) (*time.Duration, error) {
+	switch d.dialect {
+	case Postgres:
+		var secs sql.NullFloat64
+		err := d.DB().QueryRowContext(ctx, "SELECT CASE WHEN pg_is_in_recovery() THEN EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()) END").Scan(&secs)
+		if err != nil || !secs.Valid {
+			return nil, err
+		}
+		lag := time.Duration(secs.Float64 * float64(time.Second))
+		return &lag, nil
+	case MySQL:
+		return d.mysqlReplicationLag(ctx)
+	default:
+		// Dialects without replicas (e.g. SQLite).
+		return nil, nil
+	}
+}
+
+// mysqlReplicationLag reads the Seconds_Behind_Source (or Seconds_Behind_Master
+// for older versions) column from the replica status. SHOW SLAVE STATUS is used
+// for versions that do not support SHOW REPLICA STATUS (MySQL < 8.0.22 and MariaDB).
+func (d *Driver) mysqlReplicationLag(ctx context.Context) (*time.Duration, error) {
+	rows, err := d.DB().QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		if rows, err = d.DB().QueryContext(ctx, "SHOW SLAVE STATUS"); err != nil {
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	// Not a replica.
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	for i, c := range columns {
+		if c != "Seconds_Behind_Source" && c != "Seconds_Behind_Master" {
+			continue
+		}
+		// NULL means the replication is not running.
+		if values[i] == nil {
+			return nil, fmt.Errorf("replication is not running")
+		}
+		secs, err := strconv.ParseInt(string(values[i]), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		lag := time.Duration(secs) * time.Second
+		return &lag, nil
+	}
+	return nil, fmt.Errorf("missing Seconds_Behind_Source column in replica status")
+}
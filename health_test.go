@@ -0,0 +1,65 @@
+package duo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriver_Health(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	drv, err := OpenDB(MySQL, db, WithMaxOpenConns(5))
+	require.NoError(t, err)
+	assert.Equal(t, 5, drv.DB().Stats().MaxOpenConnections)
+
+	mock.ExpectPing()
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SHOW REPLICA STATUS").
+		WillReturnRows(sqlmock.NewRows([]string{"Replica_IO_State", "Seconds_Behind_Source"}).AddRow("Waiting", "3"))
+	report, err := drv.Health(context.Background(), WithReplicationLag())
+	require.NoError(t, err)
+	require.NotNil(t, report.ReplicationLag)
+	assert.Equal(t, 3*time.Second, *report.ReplicationLag)
+	assert.Equal(t, 5, report.Stats.MaxOpenConnections)
+
+	mock.ExpectPing()
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SHOW REPLICA STATUS").WillReturnRows(sqlmock.NewRows([]string{"Seconds_Behind_Source"}))
+	report, err = drv.Health(context.Background(), WithReplicationLag())
+	require.NoError(t, err)
+	assert.Nil(t, report.ReplicationLag)
+
+	// MySQL < 8.0.22 and MariaDB.
+	mock.ExpectPing()
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SHOW REPLICA STATUS").WillReturnError(assert.AnError)
+	mock.ExpectQuery("SHOW SLAVE STATUS").
+		WillReturnRows(sqlmock.NewRows([]string{"Slave_IO_State", "Seconds_Behind_Master"}).AddRow("Waiting", "5"))
+	report, err = drv.Health(context.Background(), WithReplicationLag())
+	require.NoError(t, err)
+	require.NotNil(t, report.ReplicationLag)
+	assert.Equal(t, 5*time.Second, *report.ReplicationLag)
+
+	mock.ExpectPing().WillReturnError(assert.AnError)
+	_, err = drv.Health(context.Background())
+	assert.ErrorIs(t, err, assert.AnError)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDriver_HealthNoReplicas(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	drv, err := OpenDB(SQLite, db)
+	require.NoError(t, err)
+	mock.ExpectPing()
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	report, err := drv.Health(context.Background(), WithReplicationLag())
+	require.NoError(t, err)
+	assert.Nil(t, report.ReplicationLag)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
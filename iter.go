@@ -0,0 +1,168 @@
+package duo
+
+import (
+	"context"
+	"reflect"
+)
+
+// Iterator scans the rows of a ColumnScanner one by one into values of type T,
+// using the same column mapping as ScanSlice. Unlike ScanSlice, it does not load
+// the whole result set into memory, and it is suitable for streaming large results.
+//
+//	it := duo.Iter[User](ctx, rows)
+//	defer it.Close()
+//	for it.Next() {
+//		u := it.Value()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+//
+// The underlying ColumnScanner is closed when the iteration is
+// done, when it fails, or when the iterator is explicitly closed.
+type Iterator[T any] struct {
+	rows   ColumnScanner
+	limit  *scanLimiter
+	scan   *rowScan
+	values []any
+	value  T
+	row    int
+	err    error
+	closed bool
+}
+
+// Iter returns an Iterator for the given rows. The context is checked
+// between rows (see CheckEvery), and the iteration stops once it is
+// canceled or the limits set by the MaxRows and MaxBytes options are exceeded.
+func Iter[T any](ctx context.Context, rows ColumnScanner, opts ...ScanOption) *Iterator[T] {
+	cfg := newScanConfig(opts)
+	it := &Iterator[T]{rows: rows, limit: newScanLimiter(ctx, cfg.run)}
+	if it.scan, it.err = scanRows[T](rows, cfg); it.err != nil {
+		it.close()
+		return it
+	}
+	it.values = it.scan.values()
+	return it
+}
+
+// Next prepares the next value for reading with the Value method.
+// It returns false when there are no more rows or an error occurred,
+// and the Err method should be consulted to distinguish between the two.
+func (it *Iterator[T]) Next() bool {
+	if it.closed {
+		return false
+	}
+	if err := it.limit.before(); err != nil {
+		it.err = err
+		it.close()
+		return false
+	}
+	if !it.rows.Next() {
+		it.err = it.rows.Err()
+		it.close()
+		return false
+	}
+	if err := it.rows.Scan(it.values...); err != nil {
+		it.err = scanFailed(it.rows, it.scan, it.row, err, it.values...)
+		it.close()
+		return false
+	}
+	if err := it.limit.after(it.values); err != nil {
+		it.err = err
+		it.close()
+		return false
+	}
+	var zero T
+	it.value = zero
+	if err := it.scan.assign(reflect.ValueOf(&it.value).Elem(), it.values...); err != nil {
+		it.err = scanFailed(it.rows, it.scan, it.row, err)
+		it.close()
+		return false
+	}
+	it.row++
+	return true
+}
+
+// Value returns the value of the current row.
+func (it *Iterator[T]) Value() T {
+	return it.value
+}
+
+// Err returns the error, if any, that was encountered during iteration.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Close closes the iterator and its underlying rows. It is safe
+// to call Close multiple times, or after the iteration is done.
+func (it *Iterator[T]) Close() error {
+	if it.closed {
+		return nil
+	}
+	it.closed = true
+	return it.rows.Close()
+}
+
+// close closes the iterator and records the close error (if any).
+func (it *Iterator[T]) close() {
+	if err := it.Close(); err != nil && it.err == nil {
+		it.err = err
+	}
+}
+
+// Each scans the rows one by one into values of type T and calls fn for each
+// of them. It stops on the first error returned by fn, and it always closes
+// the given rows.
+//
+//	err := duo.Each(ctx, rows, func(u User) error {
+//		return enc.Encode(u)
+//	})
+func Each[T any](ctx context.Context, rows ColumnScanner, fn func(T) error, opts ...ScanOption) error {
+	it := Iter[T](ctx, rows, opts...)
+	defer it.Close()
+	for it.Next() {
+		if err := fn(it.Value()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// ScanChan scans the rows on a separate goroutine into values of type T, and
+// delivers them over the returned channel, which is buffered with the given size.
+// The values channel is closed when the iteration is done, and the error channel
+// then receives the terminal error of the iteration (or nil). The rows are closed
+// when the iteration is done or the context is canceled. Consumers that stop
+// reading before the values channel is closed must cancel the context to release
+// the goroutine and the rows. For example:
+//
+//	users, errc := duo.ScanChan[User](ctx, rows, 100)
+//	for u := range users {
+//		...
+//	}
+//	if err := <-errc; err != nil {
+//		return err
+//	}
+func ScanChan[T any](ctx context.Context, rows ColumnScanner, buffer int, opts ...ScanOption) (<-chan T, <-chan error) {
+	var (
+		values = make(chan T, buffer)
+		errc   = make(chan error, 1)
+	)
+	go func() {
+		defer close(errc)
+		defer close(values)
+		it := Iter[T](ctx, rows, opts...)
+		defer it.Close()
+		for it.Next() {
+			select {
+			case values <- it.Value():
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+		errc <- it.Err()
+	}()
+	return values, errc
+}
@@ -0,0 +1,92 @@
+package duo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIter(t *testing.T) {
+	type User struct {
+		ID   int
+		Name string
+	}
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectQuery("").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(2, "bar")).
+		RowsWillBeClosed()
+	rows, err := db.Query("")
+	require.NoError(t, err)
+
+	var users []User
+	it := Iter[User](context.Background(), rows)
+	for it.Next() {
+		users = append(users, it.Value())
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []User{{1, "foo"}, {2, "bar"}}, users)
+	require.NoError(t, it.Close())
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mrows := sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(2, "bar")
+	err = Each(ctx, toRows(mrows), func(u User) error {
+		cancel()
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	errStop := errors.New("stop")
+	mrows = sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(2, "bar")
+	err = Each(context.Background(), toRows(mrows), func(u User) error {
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+
+	it = Iter[User](context.Background(), toRows(sqlmock.NewRows([]string{"id", "age"}).AddRow(1, 2)))
+	require.False(t, it.Next())
+	require.EqualError(t, it.Err(), "sql/scan: missing struct field for column: age (age)")
+}
+
+func TestScanChan(t *testing.T) {
+	type User struct {
+		ID   int
+		Name string
+	}
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectQuery("").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(2, "bar")).
+		RowsWillBeClosed()
+	rows, err := db.Query("")
+	require.NoError(t, err)
+
+	var users []User
+	values, errc := ScanChan[User](context.Background(), rows, 1)
+	for u := range values {
+		users = append(users, u)
+	}
+	require.NoError(t, <-errc)
+	assert.Equal(t, []User{{1, "foo"}, {2, "bar"}}, users)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mrows := sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(2, "bar").AddRow(3, "baz")
+	values, errc = ScanChan[User](ctx, toRows(mrows), 0)
+	u := <-values
+	assert.Equal(t, User{1, "foo"}, u)
+	cancel()
+	for range values {
+	}
+	require.ErrorIs(t, <-errc, context.Canceled)
+
+	values, errc = ScanChan[User](context.Background(), toRows(sqlmock.NewRows([]string{"age"}).AddRow(1)), 0)
+	_, ok := <-values
+	require.False(t, ok)
+	require.EqualError(t, <-errc, "sql/scan: missing struct field for column: age (age)")
+}
@@ -0,0 +1,184 @@
+package duo
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Leak describes a Rows or a Tx that was opened
+// by the Driver and was not closed (or finished) yet.
+type Leak struct {
+	// Kind is either "rows" or "tx".
+	Kind string
+	// Query that opened the Rows. Empty for transactions.
+	Query string
+	// Created is the creation time of the resource.
+	Created time.Time
+	// Stack is the stack trace of the goroutine that opened the resource.
+	Stack string
+}
+
+// String implements the fmt.Stringer interface.
+func (l Leak) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "unclosed %s (opened %s ago)", l.Kind, time.Since(l.Created).Round(time.Millisecond))
+	if l.Query != "" {
+		fmt.Fprintf(&b, ": %s", l.Query)
+	}
+	b.WriteString("\n")
+	b.WriteString(l.Stack)
+	return b.String()
+}
+
+// WithLeakTracking enables the tracking of the Rows returned by Conn.Query and
+// the transactions started by Driver.BeginTx. The creation stack trace of each
+// resource is recorded until it is closed, committed or rolled back. It should
+// be used in tests and debug builds, as capturing stack traces is expensive.
+func WithLeakTracking() Option {
+	return func(d *Driver) {
+		d.tracker = &tracker{open: make(map[uint64]*Leak)}
+	}
+}
+
+// Leaks returns the Rows and transactions that are still open and were created
+// more than the given duration ago. A zero duration returns all open resources.
+// Leaks returns nil if leak tracking was not enabled with WithLeakTracking.
+func (d *Driver) Leaks(age time.Duration) []Leak {
+	if d.tracker == nil {
+		return nil
+	}
+	return d.tracker.leaks(age)
+}
+
+// TestingT is the subset of testing.TB used by CheckLeaks.
+type TestingT interface {
+	Helper()
+	Cleanup(func())
+	Errorf(format string, args ...any)
+}
+
+// CheckLeaks enables leak tracking on the driver (if it was not enabled
+// already), and reports all Rows and transactions that are still open
+// at the end of the test.
+//
+//	func TestUsers(t *testing.T) {
+//		drv := openTestDriver(t)
+//		duo.CheckLeaks(t, drv)
+//		...
+//	}
+func CheckLeaks(t TestingT, d *Driver) {
+	t.Helper()
+	if d.tracker == nil {
+		WithLeakTracking()(d)
+	}
+	t.Cleanup(func() {
+		t.Helper()
+		for _, l := range d.Leaks(0) {
+			t.Errorf("sql/leak: %s", l)
+		}
+	})
+}
+
+// tracker records the open resources of a driver.
+type tracker struct {
+	mu   sync.Mutex
+	seq  uint64
+	open map[uint64]*Leak
+}
+
+// track records a new resource and returns a function for releasing it.
+// The returned function is safe to call multiple times.
+func (t *tracker) track(kind, query string) func() {
+	l := &Leak{Kind: kind, Query: query, Created: time.Now(), Stack: string(debug.Stack())}
+	t.mu.Lock()
+	t.seq++
+	id := t.seq
+	t.open[id] = l
+	t.mu.Unlock()
+	return func() {
+		t.mu.Lock()
+		delete(t.open, id)
+		t.mu.Unlock()
+	}
+}
+
+func (t *tracker) leaks(age time.Duration) []Leak {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var leaks []Leak
+	for _, l := range t.open {
+		if time.Since(l.Created) >= age {
+			leaks = append(leaks, *l)
+		}
+	}
+	sort.Slice(leaks, func(i, j int) bool {
+		return leaks[i].Created.Before(leaks[j].Created)
+	})
+	return leaks
+}
+
+func (t *tracker) trackRows(rows ColumnScanner, query string) ColumnScanner {
+	return &trackedRows{ColumnScanner: rows, tracker: t, query: query, release: t.track("rows", query)}
+}
+
+func (t *tracker) trackTx(tx driver.Tx) driver.Tx {
+	return &trackedTx{Tx: tx, release: t.track("tx", "")}
+}
+
+// trackedRows releases the tracked Rows on Close, or when they are exhausted.
+type trackedRows struct {
+	ColumnScanner
+	tracker *tracker
+	query   string
+	release func()
+}
+
+// Next prepares the next result row. The rows are released when the
+// result set is exhausted, as database/sql closes them automatically.
+func (r *trackedRows) Next() bool {
+	if r.ColumnScanner.Next() {
+		return true
+	}
+	r.release()
+	return false
+}
+
+// NextResultSet prepares the next result set for reading,
+// and tracks the rows again until it is exhausted.
+func (r *trackedRows) NextResultSet() bool {
+	if !r.ColumnScanner.NextResultSet() {
+		return false
+	}
+	r.release()
+	r.release = r.tracker.track("rows", r.query)
+	return true
+}
+
+// Close closes the underlying Rows and stops tracking it.
+func (r *trackedRows) Close() error {
+	r.release()
+	return r.ColumnScanner.Close()
+}
+
+// trackedTx releases the tracked Tx on Commit or Rollback.
+type trackedTx struct {
+	driver.Tx
+	release func()
+}
+
+// Commit commits the underlying transaction and stops tracking it.
+func (t *trackedTx) Commit() error {
+	t.release()
+	return t.Tx.Commit()
+}
+
+// Rollback aborts the underlying transaction and stops tracking it.
+func (t *trackedTx) Rollback() error {
+	t.release()
+	return t.Tx.Rollback()
+}
@@ -0,0 +1,93 @@
+package duo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriver_Leaks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	drv, err := OpenDB(MySQL, db, WithLeakTracking())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT name FROM users").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("a8m"))
+	var rows Rows
+	require.NoError(t, drv.Query(ctx, "SELECT name FROM users", []any{}, &rows))
+	mock.ExpectBegin()
+	tx, err := drv.Tx(ctx)
+	require.NoError(t, err)
+
+	leaks := drv.Leaks(0)
+	require.Len(t, leaks, 2)
+	assert.Equal(t, "rows", leaks[0].Kind)
+	assert.Equal(t, "SELECT name FROM users", leaks[0].Query)
+	assert.Contains(t, leaks[0].Stack, "TestDriver_Leaks")
+	assert.Equal(t, "tx", leaks[1].Kind)
+
+	require.NoError(t, rows.Close())
+	mock.ExpectRollback()
+	require.NoError(t, tx.Rollback())
+	assert.Empty(t, drv.Leaks(0))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDriver_LeaksExhausted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	drv, err := OpenDB(MySQL, db, WithLeakTracking())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	tx, err := drv.Tx(ctx)
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT name FROM users").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("a8m"))
+	var rows Rows
+	require.NoError(t, tx.Query(ctx, "SELECT name FROM users", []any{}, &rows))
+	require.Len(t, drv.Leaks(0), 2)
+	for rows.Next() {
+	}
+	leaks := drv.Leaks(0)
+	require.Len(t, leaks, 1)
+	assert.Equal(t, "tx", leaks[0].Kind)
+
+	// The embedded ExecContextQuery of the Tx is kept.
+	var _ ExecContextQuery = tx.ExecContextQuery
+	mock.ExpectCommit()
+	require.NoError(t, tx.Commit())
+	assert.Empty(t, drv.Leaks(0))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckLeaks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	drv, err := OpenDB(SQLite, db)
+	require.NoError(t, err)
+	rec := &recordT{}
+	CheckLeaks(rec, drv)
+	mock.ExpectBegin()
+	_, err = drv.Tx(context.Background())
+	require.NoError(t, err)
+	rec.cleanup()
+	require.Len(t, rec.errors, 1)
+	assert.Contains(t, rec.errors[0], "unclosed tx")
+}
+
+type recordT struct {
+	cleanup func()
+	errors  []string
+}
+
+func (*recordT) Helper()            {}
+func (r *recordT) Cleanup(f func()) { r.cleanup = f }
+func (r *recordT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
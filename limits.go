@@ -0,0 +1,119 @@
+package duo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ResultTooLargeError is returned by the scanning functions when the result
+// set exceeds the limits that were set using the MaxRows or MaxBytes options.
+type ResultTooLargeError struct {
+	// Limit is the exceeded limit: "rows" or "bytes".
+	Limit string
+	// Max is the value of the exceeded limit.
+	Max int64
+	// Rows and Bytes hold the number of rows and the (approximate)
+	// number of bytes that were read when the limit was exceeded.
+	Rows  int
+	Bytes int64
+}
+
+// Error implements the error interface.
+func (e *ResultTooLargeError) Error() string {
+	return fmt.Sprintf("sql/scan: result too large: exceeds %d %s", e.Max, e.Limit)
+}
+
+// CheckEvery sets the number of rows between checks of the context cancellation
+// in the context-aware scanning functions (ScanSliceContext and Iter). Defaults
+// to 1, checking the context before every row.
+func CheckEvery(n int) ScanOption {
+	return func(c *scanConfig) {
+		c.run.checkEvery = n
+	}
+}
+
+// MaxRows fails the scan with a ResultTooLargeError if the
+// result set contains more than n rows.
+func MaxRows(n int) ScanOption {
+	return func(c *scanConfig) {
+		c.run.maxRows = n
+	}
+}
+
+// MaxBytes fails the scan with a ResultTooLargeError if the total size of the
+// scanned values exceeds n bytes. The size is approximated from the lengths of
+// textual and binary values, and the sizes of the other types.
+func MaxBytes(n int64) ScanOption {
+	return func(c *scanConfig) {
+		c.run.maxBytes = n
+	}
+}
+
+// scanLimiter enforces the context cancellation and
+// the limits of a scan between the rows it reads.
+type scanLimiter struct {
+	ctx   context.Context
+	cfg   runConfig
+	rows  int
+	bytes int64
+}
+
+func newScanLimiter(ctx context.Context, cfg runConfig) *scanLimiter {
+	if cfg.checkEvery <= 0 {
+		cfg.checkEvery = 1
+	}
+	return &scanLimiter{ctx: ctx, cfg: cfg}
+}
+
+// before is called before reading the next row.
+func (l *scanLimiter) before() error {
+	if l.rows%l.cfg.checkEvery == 0 {
+		return l.ctx.Err()
+	}
+	return nil
+}
+
+// after is called with the scan destinations of each row that was read.
+func (l *scanLimiter) after(values []any) error {
+	l.rows++
+	if max := l.cfg.maxRows; max > 0 && l.rows > max {
+		return &ResultTooLargeError{Limit: "rows", Max: int64(max), Rows: l.rows, Bytes: l.bytes}
+	}
+	if max := l.cfg.maxBytes; max > 0 {
+		for _, v := range values {
+			l.bytes += scannedSize(v)
+		}
+		if l.bytes > max {
+			return &ResultTooLargeError{Limit: "bytes", Max: max, Rows: l.rows, Bytes: l.bytes}
+		}
+	}
+	return nil
+}
+
+// scannedSize returns the approximate size of the value held by the given scan destination.
+func scannedSize(dest any) int64 {
+	switch v := dest.(type) {
+	case *sql.NullString:
+		return int64(len(v.String))
+	case *sql.RawBytes:
+		return int64(len(*v))
+	case *[]byte:
+		return int64(len(*v))
+	case *string:
+		return int64(len(*v))
+	case *sql.NullTime, *time.Time:
+		return 24
+	case *any:
+		switch v := (*v).(type) {
+		case nil:
+			return 0
+		case string:
+			return int64(len(v))
+		case []byte:
+			return int64(len(v))
+		}
+	}
+	return 8
+}
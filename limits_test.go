@@ -0,0 +1,56 @@
+package duo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanSliceContext(t *testing.T) {
+	type User struct {
+		ID   int
+		Name string
+	}
+	newRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "foo").
+			AddRow(2, strings.Repeat("x", 100)).
+			AddRow(3, "baz")
+	}
+	users, err := ScanSliceContext[User](context.Background(), toRows(newRows()), MaxRows(3))
+	require.NoError(t, err)
+	assert.Len(t, users, 3)
+
+	_, err = ScanSliceContext[User](context.Background(), toRows(newRows()), MaxRows(2))
+	var re *ResultTooLargeError
+	require.True(t, errors.As(err, &re))
+	assert.Equal(t, &ResultTooLargeError{Limit: "rows", Max: 2, Rows: 3}, re)
+	assert.EqualError(t, err, "sql/scan: result too large: exceeds 2 rows")
+
+	_, err = ScanSlice[User](toRows(newRows()), MaxBytes(100))
+	require.True(t, errors.As(err, &re))
+	assert.Equal(t, &ResultTooLargeError{Limit: "bytes", Max: 100, Rows: 2, Bytes: 119}, re)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ScanSliceContext[User](ctx, toRows(newRows()))
+	require.ErrorIs(t, err, context.Canceled)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	it := Iter[User](ctx, toRows(newRows()), CheckEvery(2))
+	require.True(t, it.Next())
+	cancel()
+	require.True(t, it.Next())
+	require.False(t, it.Next())
+	require.ErrorIs(t, it.Err(), context.Canceled)
+
+	it = Iter[User](context.Background(), toRows(newRows()), MaxRows(1))
+	require.True(t, it.Next())
+	require.False(t, it.Next())
+	require.EqualError(t, it.Err(), "sql/scan: result too large: exceeds 1 rows")
+}
@@ -0,0 +1,252 @@
+package duo
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Model describes the table of a struct type. It is derived once from the struct tags,
+// using the same rules as the scanning functions, and it can be passed to the builders
+// to keep the struct as the single source of truth of the queries. For example:
+//
+//	type User struct {
+//		_       struct{}   `sql:"users,schema=public"`
+//		ID      int        `sql:"id,pk,auto"`
+//		Name    string     `sql:"name"`
+//		Version int        `sql:"version,version"`
+//		Deleted *time.Time `sql:"deleted_at,softdelete"`
+//	}
+//
+//	m := duo.ModelOf[User]()
+//	query, args := duo.Select(m.Columns()...).
+//		From(m.Table()).
+//		Where(duo.And(duo.EQ("name", "a8m"), m.NotDeleted())).
+//		Query()
+//
+// The table name and the schema are taken from the tag of a blank (_) field, and
+// the table name defaults to the struct name formatted by the naming strategy.
+// Column fields support the following tag options:
+//
+//	pk          a primary key column. Defaults to the "id" column.
+//	auto        an auto-generated column, skipped on insert when zero.
+//	version     the version column that is used for optimistic locking.
+//	softdelete  the column that marks a row as deleted when it is not NULL.
+type Model struct {
+	typ     reflect.Type
+	naming  *NamingStrategy
+	name    string
+	schema  string
+	fields  []structField
+	columns []string
+	pk      []string
+	auto    []string
+	version string
+	deleted string
+}
+
+// modelKey is the cache key of models.
+type modelKey struct {
+	typ    reflect.Type
+	naming namingKey
+}
+
+// models caches the models of struct types.
+var models sync.Map
+
+// ModelOf returns the model of the struct type T. It panics if T is not
+// a valid model, and it is meant to be used in variable declarations:
+//
+//	var users = duo.ModelOf[User]()
+func ModelOf[T any]() *Model {
+	m, err := modelOf(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// NewModel returns the model of the given struct (or pointer to struct) type.
+func NewModel(v any) (*Model, error) {
+	typ := reflect.TypeOf(v)
+	if typ == nil {
+		return nil, fmt.Errorf("sql: invalid model type %T. expect a struct or a pointer to a struct", v)
+	}
+	return modelOf(typ)
+}
+
+// modelOf returns the cached model of the given type.
+func modelOf(typ reflect.Type) (*Model, error) {
+	if typ = indirect(typ); typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("sql: invalid model type %s. expect a struct or a pointer to a struct", typ)
+	}
+	n := DefaultNaming()
+	key := modelKey{typ: typ, naming: n.key()}
+	if m, ok := models.Load(key); ok {
+		return m.(*Model), nil
+	}
+	m, err := newModel(typ, n)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := models.LoadOrStore(key, m)
+	return actual.(*Model), nil
+}
+
+// newModel parses the model of the given struct type.
+func newModel(typ reflect.Type, n *NamingStrategy) (*Model, error) {
+	m := &Model{typ: typ, naming: n, name: n.name(typ.Name())}
+	if f, ok := typ.FieldByName("_"); ok {
+		name, opts, tagged := n.parseTag(f)
+		if tagged {
+			m.name = name
+		}
+		m.schema, _ = opts.Value("schema")
+	}
+	if m.name == "" {
+		return nil, fmt.Errorf("sql: missing table name of model %s", typ)
+	}
+	m.fields = valueFields(typ, n)
+	for _, f := range m.fields {
+		m.columns = append(m.columns, f.name)
+		if f.opts.Has("auto") {
+			m.auto = append(m.auto, f.name)
+		}
+		if f.opts.Has("version") {
+			if m.version != "" {
+				return nil, fmt.Errorf("sql: multiple version columns in model %s", typ)
+			}
+			if k := f.typ.Kind(); k < reflect.Int || k > reflect.Uint64 {
+				return nil, fmt.Errorf("sql: invalid type %s for version column %q. expect an integer", f.typ, f.name)
+			}
+			m.version = f.name
+		}
+		if f.opts.Has("softdelete") {
+			if m.deleted != "" {
+				return nil, fmt.Errorf("sql: multiple soft-delete columns in model %s", typ)
+			}
+			m.deleted = f.name
+		}
+	}
+	for _, f := range primaryKey(m.fields, n) {
+		m.pk = append(m.pk, f.name)
+	}
+	return m, nil
+}
+
+// Type returns the struct type of the model.
+func (m *Model) Type() reflect.Type { return m.typ }
+
+// Name returns the table name of the model.
+func (m *Model) Name() string { return m.name }
+
+// Schema returns the schema name of the model, if it was set.
+func (m *Model) Schema() string { return m.schema }
+
+// Columns returns the columns of the model, in the order of the struct fields.
+func (m *Model) Columns() []string { return append([]string(nil), m.columns...) }
+
+// PrimaryKey returns the primary key columns of the model.
+func (m *Model) PrimaryKey() []string { return append([]string(nil), m.pk...) }
+
+// AutoColumns returns the auto-generated columns of the model.
+func (m *Model) AutoColumns() []string { return append([]string(nil), m.auto...) }
+
+// VersionColumn returns the version column of the model, or an empty string.
+func (m *Model) VersionColumn() string { return m.version }
+
+// SoftDeleteColumn returns the soft-delete column of the model, or an empty string.
+func (m *Model) SoftDeleteColumn() string { return m.deleted }
+
+// Table returns the table selector of the model.
+func (m *Model) Table() *SelectTable {
+	return Table(m.name).Schema(m.schema)
+}
+
+// Select returns a selector of the given columns (or all model columns, if
+// no columns were given) from the model table, qualified by the table name.
+func (m *Model) Select(columns ...string) *Selector {
+	if len(columns) == 0 {
+		columns = m.columns
+	}
+	t := m.Table()
+	selection := make([]string, len(columns))
+	for i := range columns {
+		selection[i] = t.C(columns[i])
+	}
+	return Select(selection...).From(t)
+}
+
+// NotDeleted returns a predicate that matches the rows that were not soft-deleted.
+// If the model does not have a soft-delete column, it returns the TRUE predicate.
+func (m *Model) NotDeleted() *Predicate {
+	if m.deleted == "" {
+		return True()
+	}
+	return IsNull(m.deleted)
+}
+
+// Insert creates a builder for the `INSERT INTO` statement of the given struct in
+// the model table, using the naming strategy of the model. See InsertStruct for details.
+func (m *Model) Insert(v any, opts ...StructOption) *InsertBuilder {
+	b := Insert(m.name).Schema(m.schema)
+	if err := m.check(reflect.TypeOf(v)); err != nil {
+		b.AddError(err)
+		return b
+	}
+	return b.structs([]reflect.Value{reflect.ValueOf(v)}, opts, m.naming)
+}
+
+// Update creates a builder for the `UPDATE` statement of the changed columns of the
+// given structs in the model table, using the naming strategy of the model. See
+// UpdateStruct for details. If the model has a version column and there are changes,
+// the statement matches only the original version and increments it. Thus, if no
+// rows were affected, the row was either deleted or modified concurrently.
+func (m *Model) Update(original, modified any) *UpdateBuilder {
+	u := Update(m.name).Schema(m.schema)
+	for _, v := range []any{original, modified} {
+		if err := m.check(reflect.TypeOf(v)); err != nil {
+			u.AddError(err)
+			return u
+		}
+	}
+	orig := reflect.ValueOf(original)
+	if u.diff(orig, reflect.ValueOf(modified), m.naming); u.Err() != nil || u.Empty() || m.version == "" {
+		return u
+	}
+	for _, f := range m.fields {
+		if f.name != m.version {
+			continue
+		}
+		fv, ok := valueByIndex(reflect.Indirect(orig), f.index)
+		if !ok {
+			u.AddError(fmt.Errorf("sql: missing value for version column %q", f.name))
+			return u
+		}
+		next := reflect.New(fv.Type()).Elem()
+		if k := fv.Kind(); k >= reflect.Int && k <= reflect.Int64 {
+			next.SetInt(fv.Int() + 1)
+		} else {
+			next.SetUint(fv.Uint() + 1)
+		}
+		u.Where(EQ(f.name, fv.Interface())).Set(f.name, next.Interface())
+	}
+	return u
+}
+
+// check checks that the given type is the model type or a pointer to it.
+func (m *Model) check(typ reflect.Type) error {
+	if typ == nil {
+		return fmt.Errorf("sql: nil value for model %s", m.typ)
+	}
+	if indirect(typ) != m.typ {
+		return fmt.Errorf("sql: invalid type %s for model %s", typ, m.typ)
+	}
+	return nil
+}
+
+// WithModel configures the scan to use the naming strategy that the given
+// model was derived with, to map its columns back to the struct fields.
+func WithModel(m *Model) ScanOption {
+	return WithNaming(m.naming)
+}
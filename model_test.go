@@ -0,0 +1,113 @@
+package duo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type modelUser struct {
+	_       struct{}   `sql:"users,schema=public"`
+	ID      int        `sql:"id,pk,auto"`
+	Name    string     `sql:"name"`
+	Version uint       `sql:"version,version"`
+	Deleted *time.Time `sql:"deleted_at,softdelete"`
+}
+
+func TestModel(t *testing.T) {
+	m := ModelOf[modelUser]()
+	require.Same(t, m, ModelOf[*modelUser]())
+	assert.Equal(t, "users", m.Name())
+	assert.Equal(t, "public", m.Schema())
+	assert.Equal(t, []string{"id", "name", "version", "deleted_at"}, m.Columns())
+	assert.Equal(t, []string{"id"}, m.PrimaryKey())
+	assert.Equal(t, []string{"id"}, m.AutoColumns())
+	assert.Equal(t, "version", m.VersionColumn())
+	assert.Equal(t, "deleted_at", m.SoftDeleteColumn())
+
+	query, _ := Dialect(Postgres).Select(m.Columns()...).From(m.Table()).Where(m.NotDeleted()).Query()
+	assert.Equal(t, `SELECT "id", "name", "version", "deleted_at" FROM "public"."users" WHERE "deleted_at" IS NULL`, query)
+	query, _ = m.Select("id").Query()
+	assert.Equal(t, "SELECT `public`.`users`.`id` FROM `public`.`users`", query)
+
+	type Event struct {
+		Name string
+	}
+	e, err := NewModel(Event{})
+	require.NoError(t, err)
+	assert.Equal(t, "event", e.Name())
+	assert.Empty(t, e.PrimaryKey())
+	query, _ = Select().From(e.Table()).Where(e.NotDeleted()).Query()
+	assert.Equal(t, "SELECT * FROM `event` WHERE TRUE", query)
+	query, args := Select().From(e.Table()).Where(And(EQ("name", "a"), e.NotDeleted())).Query()
+	assert.Equal(t, "SELECT * FROM `event` WHERE `name` = ? AND TRUE", query)
+	assert.Equal(t, []any{"a"}, args)
+
+	_, err = NewModel(1)
+	require.EqualError(t, err, "sql: invalid model type int. expect a struct or a pointer to a struct")
+	type Doc struct {
+		V string `sql:"v,version"`
+	}
+	_, err = NewModel(Doc{})
+	require.EqualError(t, err, `sql: invalid type string for version column "v". expect an integer`)
+	_, err = NewModel(struct{ V int }{})
+	require.EqualError(t, err, "sql: missing table name of model struct { V int }")
+	require.Panics(t, func() { ModelOf[int]() })
+}
+
+func TestModel_Builders(t *testing.T) {
+	m := ModelOf[modelUser]()
+	b := m.Insert(&modelUser{Name: "a8m"})
+	b.SetDialect(Postgres)
+	query, args := b.Query()
+	assert.Equal(t, `INSERT INTO "public"."users" ("name", "version", "deleted_at") VALUES ($1, $2, $3)`, query)
+	assert.Equal(t, []any{"a8m", uint(0), (*time.Time)(nil)}, args)
+
+	orig := modelUser{ID: 1, Name: "a8m", Version: 2}
+	mod := orig
+	mod.Name = "foo"
+	u := m.Update(orig, &mod)
+	u.SetDialect(Postgres)
+	query, args = u.Query()
+	assert.Equal(t, `UPDATE "public"."users" SET "name" = $1, "version" = $2 WHERE "id" = $3 AND "version" = $4`, query)
+	assert.Equal(t, []any{"foo", uint(3), 1, uint(2)}, args)
+	require.True(t, m.Update(orig, orig).Empty())
+
+	require.EqualError(t, m.Insert(struct{}{}).Err(), "sql: invalid type struct {} for model duo.modelUser")
+	require.EqualError(t, m.Insert(nil).Err(), "sql: nil value for model duo.modelUser")
+	require.EqualError(t, m.Update(orig, nil).Err(), "sql: nil value for model duo.modelUser")
+
+	rows := toRows(sqlmock.NewRows([]string{"id", "name", "version", "deleted_at"}).AddRow(1, "a8m", 2, nil))
+	users, err := ScanSlice[modelUser](rows, WithModel(m))
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, orig, users[0])
+}
+
+func TestModel_Naming(t *testing.T) {
+	type Account struct {
+		ID        int    `db:"id,pk,auto"`
+		FirstName string `db:",omitempty"`
+		Version   int    `db:",version"`
+	}
+	SetNaming(&NamingStrategy{Tags: []string{"db"}, Name: SnakeCase})
+	m := ModelOf[Account]()
+	SetNaming(&NamingStrategy{})
+
+	// The builders of the model use the naming strategy it was derived with.
+	assert.Equal(t, []string{"id", "first_name", "version"}, m.Columns())
+	b := m.Insert(Account{FirstName: "a8m"})
+	b.SetDialect(Postgres)
+	query, args := b.Query()
+	assert.Equal(t, `INSERT INTO "account" ("first_name", "version") VALUES ($1, $2)`, query)
+	assert.Equal(t, []any{"a8m", 0}, args)
+
+	u := m.Update(Account{ID: 1, Version: 1}, Account{ID: 1, FirstName: "foo", Version: 1})
+	u.SetDialect(Postgres)
+	query, args = u.Query()
+	assert.Equal(t, `UPDATE "account" SET "first_name" = $1, "version" = $2 WHERE "id" = $3 AND "version" = $4`, query)
+	assert.Equal(t, []any{"foo", 2, 1, 1}, args)
+}
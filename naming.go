@@ -0,0 +1,177 @@
+package duo
+
+import (
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// NamingStrategy defines how struct fields are mapped to column names.
+//
+//	duo.SetNaming(&duo.NamingStrategy{
+//		Tags: []string{"db"},
+//		Name: duo.SnakeCase,
+//	})
+type NamingStrategy struct {
+	// Tags are the struct tag keys that are consulted, in order, for an explicit
+	// column name and the tag options of a field. Defaults to "sql" and "json".
+	Tags []string
+	// Name returns the column name of a field that does not have an
+	// explicit name in its tags. Defaults to strings.ToLower.
+	Name func(field string) string
+	// Normalize normalizes the column names of the result set and the struct
+	// fields before they are matched. Defaults to NormalizeColumn.
+	Normalize func(column string) string
+}
+
+// naming is the global naming strategy.
+var naming atomic.Value
+
+func init() {
+	naming.Store(&NamingStrategy{})
+}
+
+// SetNaming sets the global naming strategy that is used by the scanning
+// functions, unless a different strategy is passed using WithNaming.
+func SetNaming(n *NamingStrategy) {
+	naming.Store(n)
+}
+
+// DefaultNaming returns the global naming strategy.
+func DefaultNaming() *NamingStrategy {
+	return naming.Load().(*NamingStrategy)
+}
+
+// WithNaming sets the naming strategy of a scan call.
+func WithNaming(n *NamingStrategy) ScanOption {
+	return func(c *scanConfig) {
+		c.naming = n
+	}
+}
+
+// ColumnName returns the column name of the given struct field.
+func (n *NamingStrategy) ColumnName(f reflect.StructField) string {
+	name, _, _ := n.parseTag(f)
+	return name
+}
+
+// tags returns the struct tag keys of the strategy.
+func (n *NamingStrategy) tags() []string {
+	if n == nil || len(n.Tags) == 0 {
+		return []string{"sql", "json"}
+	}
+	return n.Tags
+}
+
+// name returns the column name of an untagged field.
+func (n *NamingStrategy) name(field string) string {
+	if n == nil || n.Name == nil {
+		return strings.ToLower(field)
+	}
+	return n.Name(field)
+}
+
+// normalize normalizes the given column name for matching.
+func (n *NamingStrategy) normalize(column string) string {
+	if n == nil || n.Normalize == nil {
+		return NormalizeColumn(column)
+	}
+	return n.Normalize(column)
+}
+
+// parseTag returns the column name and the options of the given field, and
+// reports if the name was taken explicitly from the first (database) tag key.
+// Names taken from the other keys (e.g. json) do not stop embedded structs
+// from being flattened.
+func (n *NamingStrategy) parseTag(f reflect.StructField) (string, tagOptions, bool) {
+	for i, key := range n.tags() {
+		tag, ok := f.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			return n.name(f.Name), tagOptions(opts), false
+		}
+		return name, tagOptions(opts), i == 0
+	}
+	return n.name(f.Name), "", false
+}
+
+// namingKey identifies a naming strategy and its state in cache keys, to
+// avoid returning stale mappings if the strategy is modified after use.
+type namingKey struct {
+	n         *NamingStrategy
+	tags      string
+	name      uintptr
+	normalize uintptr
+}
+
+// key returns the cache key of the strategy.
+func (n *NamingStrategy) key() namingKey {
+	k := namingKey{n: n}
+	if n != nil {
+		k.tags = strings.Join(n.Tags, "\x00")
+		k.name = funcPointer(n.Name)
+		k.normalize = funcPointer(n.Normalize)
+	}
+	return k
+}
+
+// funcPointer returns the code pointer of the given function, or 0 if it is nil.
+func funcPointer(f func(string) string) uintptr {
+	if f == nil {
+		return 0
+	}
+	return reflect.ValueOf(f).Pointer()
+}
+
+// NormalizeColumn is the default column normalization. It lowercases the column
+// name, and strips function calls from it. For example, COUNT(*) => count.
+func NormalizeColumn(column string) string {
+	return strings.ToLower(strings.Split(column, "(")[0])
+}
+
+// SnakeCase converts the given Go identifier to snake_case.
+// For example, CreatedAt => created_at, UserID => user_id.
+func SnakeCase(s string) string {
+	var (
+		b  strings.Builder
+		rs = []rune(s)
+	)
+	for i, r := range rs {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := rs[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && i+1 < len(rs) && unicode.IsLower(rs[i+1]) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// CamelCase converts the given Go identifier to camelCase.
+// For example, CreatedAt => createdAt, ID => id, HTTPServer => httpServer.
+func CamelCase(s string) string {
+	rs := []rune(s)
+	n := 0
+	for n < len(rs) && unicode.IsUpper(rs[n]) {
+		n++
+	}
+	// Keep the last uppercase letter of an acronym
+	// that is followed by a lowercase letter.
+	if n > 1 && n < len(rs) && unicode.IsLower(rs[n]) {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		rs[i] = unicode.ToLower(rs[i])
+	}
+	return string(rs)
+}
+
+// ExactCase returns the Go identifier as is.
+func ExactCase(s string) string {
+	return s
+}
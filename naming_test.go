@@ -0,0 +1,84 @@
+package duo
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamingCase(t *testing.T) {
+	for name, want := range map[string][2]string{
+		"ID":         {"id", "id"},
+		"CreatedAt":  {"created_at", "createdAt"},
+		"UserID":     {"user_id", "userID"},
+		"HTTPServer": {"http_server", "httpServer"},
+		"Address2":   {"address2", "address2"},
+	} {
+		assert.Equal(t, want[0], SnakeCase(name), name)
+		assert.Equal(t, want[1], CamelCase(name), name)
+	}
+}
+
+func TestScanSlice_Naming(t *testing.T) {
+	type User struct {
+		ID        int
+		FullName  string `db:"name"`
+		CreatedAt time.Time
+	}
+	now := time.Now()
+	newRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "created_at"}).AddRow(1, "a8m", now)
+	}
+	_, err := ScanSlice[User](toRows(newRows()))
+	require.Error(t, err)
+
+	snake := &NamingStrategy{Tags: []string{"db"}, Name: SnakeCase}
+	users, err := ScanSlice[User](toRows(newRows()), WithNaming(snake))
+	require.NoError(t, err)
+	assert.Equal(t, []User{{1, "a8m", now}}, users)
+
+	SetNaming(snake)
+	defer SetNaming(&NamingStrategy{})
+	users, err = ScanSlice[User](toRows(newRows()))
+	require.NoError(t, err)
+	assert.Equal(t, []User{{1, "a8m", now}}, users)
+
+	exact := &NamingStrategy{Name: ExactCase, Normalize: ExactCase}
+	_, err = ScanSlice[User](toRows(newRows()), WithNaming(exact))
+	require.EqualError(t, err, "sql/scan: missing struct field for column: id (id)")
+}
+
+func TestScanSlice_NamingEmbedded(t *testing.T) {
+	type (
+		Base struct {
+			ID int `json:"id"`
+		}
+		User struct {
+			Base `json:"base"`
+			Name string
+		}
+	)
+	users, err := ScanSlice[User](toRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a8m")))
+	require.NoError(t, err)
+	assert.Equal(t, []User{{Base{1}, "a8m"}}, users)
+}
+
+func TestScanSlice_NamingChanged(t *testing.T) {
+	type User struct {
+		FullName string
+	}
+	newRows := func() *sql.Rows {
+		return toRows(sqlmock.NewRows([]string{"full_name"}).AddRow("a8m"))
+	}
+	n := &NamingStrategy{}
+	_, err := ScanSlice[User](newRows(), WithNaming(n))
+	require.Error(t, err)
+	n.Name = SnakeCase
+	users, err := ScanSlice[User](newRows(), WithNaming(n))
+	require.NoError(t, err)
+	assert.Equal(t, []User{{"a8m"}}, users)
+}
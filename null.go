@@ -0,0 +1,174 @@
+package duo
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ErrUnexpectedNull is returned (wrapped in a ScanError) when a NULL value is
+// scanned into a non-nullable field, and the StrictNull option is enabled.
+var ErrUnexpectedNull = errors.New("sql/scan: NULL value into non-nullable field")
+
+// StrictNull fails the scan when a NULL value is scanned into a non-nullable field,
+// instead of leaving its zero value. Pointers, Null[T], sql.Null* and other sql.Scanner
+// fields, slices, maps and interfaces are considered nullable, as well as the fields of
+// pointer nested structs, which are left nil if all their columns are NULL.
+func StrictNull() ScanOption {
+	return func(c *scanConfig) {
+		c.strictNull = true
+	}
+}
+
+// Null represents a value of type T that may be NULL. It implements the sql.Scanner
+// and driver.Valuer interfaces, and it is marshaled to JSON as T or as null.
+//
+//	type User struct {
+//		ID       int
+//		Nickname duo.Null[string]
+//		Age      duo.Null[int]
+//	}
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+// NullOf returns a valid Null[T] holding the given value.
+func NullOf[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+// Ptr returns a pointer to the value, or nil if it is NULL.
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	return &n.V
+}
+
+// Scan implements the sql.Scanner interface.
+func (n *Null[T]) Scan(src any) error {
+	var zero T
+	n.V, n.Valid = zero, false
+	if src == nil {
+		return nil
+	}
+	if err := convertValue(reflect.ValueOf(&n.V).Elem(), src); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (n Null[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	if vr, ok := any(n.V).(driver.Valuer); ok {
+		return vr.Value()
+	}
+	return driver.DefaultParameterConverter.ConvertValue(n.V)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.V)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (n *Null[T]) UnmarshalJSON(b []byte) error {
+	var zero T
+	n.V, n.Valid = zero, false
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		return nil
+	}
+	if err := json.Unmarshal(b, &n.V); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// null marks the Null type for the scanning functions.
+func (Null[T]) null() {}
+
+// nullType is implemented by all instances of Null.
+var nullType = reflect.TypeOf((*interface{ null() })(nil)).Elem()
+
+// convertValue converts a (non-NULL) value returned by the driver to the type
+// of dst, similar to the conversions of the database/sql package.
+func convertValue(dst reflect.Value, src any) error {
+	if sc, ok := dst.Addr().Interface().(sql.Scanner); ok {
+		return sc.Scan(src)
+	}
+	var (
+		sv = reflect.ValueOf(src)
+		dk = dst.Kind()
+	)
+	switch src := src.(type) {
+	case []byte:
+		if dk == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes(append([]byte(nil), src...))
+			return nil
+		}
+		return setElem(dst, string(src))
+	case string:
+		if dk == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes([]byte(src))
+			return nil
+		}
+		return setElem(dst, src)
+	case time.Time:
+		if dk == reflect.String {
+			dst.SetString(src.Format(time.RFC3339Nano))
+			return nil
+		}
+	}
+	if sv.Type().AssignableTo(dst.Type()) {
+		dst.Set(sv)
+		return nil
+	}
+	sk := sv.Kind()
+	switch {
+	case sk >= reflect.Int && sk <= reflect.Int64 && dk >= reflect.Int && dk <= reflect.Int64:
+		if n := sv.Int(); !dst.OverflowInt(n) {
+			dst.SetInt(n)
+			return nil
+		}
+		return fmt.Errorf("value %v overflows %s", src, dst.Type())
+	case sk >= reflect.Int && sk <= reflect.Int64 && dk >= reflect.Uint && dk <= reflect.Uintptr:
+		if n := sv.Int(); n >= 0 && !dst.OverflowUint(uint64(n)) {
+			dst.SetUint(uint64(n))
+			return nil
+		}
+		return fmt.Errorf("value %v overflows %s", src, dst.Type())
+	case sk >= reflect.Int && sk <= reflect.Int64 && (dk == reflect.Float32 || dk == reflect.Float64):
+		dst.SetFloat(float64(sv.Int()))
+		return nil
+	case sk >= reflect.Int && sk <= reflect.Int64 && dk == reflect.Bool:
+		dst.SetBool(sv.Int() != 0)
+		return nil
+	case (sk == reflect.Float32 || sk == reflect.Float64) && (dk == reflect.Float32 || dk == reflect.Float64):
+		dst.SetFloat(sv.Float())
+		return nil
+	case (sk == reflect.Float32 || sk == reflect.Float64) && dk >= reflect.Int && dk <= reflect.Int64:
+		if f := sv.Float(); f == float64(int64(f)) && !dst.OverflowInt(int64(f)) {
+			dst.SetInt(int64(f))
+			return nil
+		}
+		return fmt.Errorf("value %v cannot be converted to %s", src, dst.Type())
+	case dk == reflect.String:
+		dst.SetString(fmt.Sprint(src))
+		return nil
+	}
+	return fmt.Errorf("unsupported conversion from %T to %s", src, dst.Type())
+}
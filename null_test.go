@@ -0,0 +1,144 @@
+package duo
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNull(t *testing.T) {
+	var n Null[int32]
+	require.NoError(t, n.Scan(int64(10)))
+	assert.Equal(t, NullOf[int32](10), n)
+	require.NoError(t, n.Scan([]byte("20")))
+	assert.Equal(t, NullOf[int32](20), n)
+	require.NoError(t, n.Scan(nil))
+	assert.Equal(t, Null[int32]{}, n)
+	assert.Nil(t, n.Ptr())
+	assert.EqualError(t, n.Scan(int64(1<<40)), "value 1099511627776 overflows int32")
+
+	v, err := NullOf[int32](5).Value()
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), v)
+	v, err = Null[string]{}.Value()
+	require.NoError(t, err)
+	assert.Nil(t, v)
+	now := time.Now()
+	v, err = NullOf(sql.NullTime{Time: now, Valid: true}).Value()
+	require.NoError(t, err)
+	assert.Equal(t, now, v)
+
+	b, err := json.Marshal([]Null[string]{NullOf("a"), {}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `["a",null]`, string(b))
+	var ns []Null[string]
+	require.NoError(t, json.Unmarshal(b, &ns))
+	assert.Equal(t, []Null[string]{NullOf("a"), {}}, ns)
+}
+
+func TestScanSlice_Null(t *testing.T) {
+	type User struct {
+		ID   int
+		Name Null[string]
+		Age  Null[int]
+	}
+	mock := sqlmock.NewRows([]string{"id", "name", "age"}).
+		AddRow(1, "foo", 30).
+		AddRow(2, nil, nil)
+	users, err := ScanSlice[User](toRows(mock))
+	require.NoError(t, err)
+	assert.Equal(t, []User{{1, NullOf("foo"), NullOf(30)}, {ID: 2}}, users)
+
+	names, err := ScanSlice[Null[string]](toRows(sqlmock.NewRows([]string{"name"}).AddRow("a").AddRow(nil).AddRow("b")))
+	require.NoError(t, err)
+	assert.Equal(t, []Null[string]{NullOf("a"), {}, NullOf("b")}, names)
+
+	type Strict struct {
+		ID   int
+		Name string
+		Age  Null[int]
+	}
+	mock = sqlmock.NewRows([]string{"id", "name", "age"}).AddRow(1, "foo", nil)
+	_, err = ScanSlice[Strict](toRows(mock), StrictNull())
+	require.NoError(t, err)
+	mock = sqlmock.NewRows([]string{"id", "name", "age"}).AddRow(1, "foo", nil).AddRow(2, nil, 1)
+	_, err = ScanSlice[Strict](toRows(mock), StrictNull())
+	assert.True(t, errors.Is(err, ErrUnexpectedNull))
+	assert.EqualError(t, err, `sql/scan: column "name" (row 1) into Strict.Name: NULL value into non-nullable field`)
+
+	// Fields of optional nested structs are nullable (e.g. a LEFT JOIN miss).
+	type Group struct {
+		ID   int
+		Name string
+	}
+	type Member struct {
+		ID    int
+		Group *Group `sql:"group,prefix=g_"`
+		Owner Group  `sql:"owner,prefix=o_"`
+	}
+	mock = sqlmock.NewRows([]string{"id", "g_id", "g_name", "o_id", "o_name"}).
+		AddRow(1, 10, "admins", 2, "bar").
+		AddRow(2, nil, nil, 3, "baz")
+	members, err := ScanSlice[Member](toRows(mock), StrictNull())
+	require.NoError(t, err)
+	assert.Equal(t, []Member{{1, &Group{10, "admins"}, Group{2, "bar"}}, {2, nil, Group{3, "baz"}}}, members)
+	mock = sqlmock.NewRows([]string{"id", "g_id", "g_name", "o_id", "o_name"}).AddRow(1, nil, nil, nil, "bar")
+	_, err = ScanSlice[Member](toRows(mock), StrictNull())
+	assert.EqualError(t, err, `sql/scan: column "o_id" (row 0) into Member.Owner.ID: NULL value into non-nullable field`)
+}
+
+func TestScanSlice_StrictNullFields(t *testing.T) {
+	registerTestConverter(t, Converter[testLevel]{
+		Scan: func(src any) (testLevel, error) {
+			return testLevel(len(src.([]byte))), nil
+		},
+	})
+	type Attrs struct {
+		Color string
+	}
+	type Item struct {
+		Level testLevel
+		Attrs Attrs   `sql:",json"`
+		Tags  []int64 `sql:"tags"`
+		Ptr   *Attrs  `sql:",json"`
+	}
+	mock := sqlmock.NewRows([]string{"level", "attrs", "tags", "ptr"}).AddRow([]byte("ab"), `{"color":"red"}`, "{1,2}", nil)
+	items, err := ScanSlice[Item](toRows(mock), StrictNull())
+	require.NoError(t, err)
+	assert.Equal(t, []Item{{Level: 2, Attrs: Attrs{"red"}, Tags: []int64{1, 2}}}, items)
+
+	// NULL arrays are allowed, as slices are nullable.
+	mock = sqlmock.NewRows([]string{"level", "attrs", "tags"}).AddRow([]byte("a"), `{}`, nil)
+	_, err = ScanSlice[Item](toRows(mock), StrictNull())
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		row  []driver.Value
+		want string
+	}{
+		{[]driver.Value{nil, `{}`, "{1}"}, `sql/scan: column "level" (row 0) into Item.Level: NULL value into non-nullable field`},
+		{[]driver.Value{[]byte("a"), nil, "{1}"}, `sql/scan: column "attrs" (row 0) into Item.Attrs: NULL value into non-nullable field`},
+		{[]driver.Value{[]byte("a"), `{}`, "{1,NULL}"}, `sql/scan: column "tags" (row 0) into Item.Tags: sql/array: element 1: sql/scan: NULL value into non-nullable field (value: {1,NULL})`},
+	} {
+		mock = sqlmock.NewRows([]string{"level", "attrs", "tags"}).AddRow(tt.row...)
+		_, err = ScanSlice[Item](toRows(mock), StrictNull())
+		assert.True(t, errors.Is(err, ErrUnexpectedNull))
+		assert.EqualError(t, err, tt.want)
+		// Without StrictNull, NULL values are left as zero values.
+		mock = sqlmock.NewRows([]string{"level", "attrs", "tags"}).AddRow(tt.row...)
+		_, err = ScanSlice[Item](toRows(mock))
+		require.NoError(t, err)
+	}
+
+	_, err = ScanSlice[[]int](toRows(sqlmock.NewRows([]string{"tags"}).AddRow("{1,NULL}")), StrictNull())
+	assert.True(t, errors.Is(err, ErrUnexpectedNull))
+	var p Array[*int]
+	require.NoError(t, p.Scan("{1,NULL}"))
+}
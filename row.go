@@ -0,0 +1,288 @@
+package duo
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Row is a dynamic result row that can be consumed without declaring a
+// struct. Column values are stored as returned by the driver, except for
+// []byte values of textual columns, that are normalized to strings using
+// the database type name reported by the driver.
+//
+//	rows, err := duo.ScanRows(rows)
+//	if err != nil {
+//		return err
+//	}
+//	for _, r := range rows {
+//		name, err := r.GetString("name")
+//		...
+//	}
+type Row struct {
+	columns []string
+	index   map[string]int
+	values  []any
+}
+
+// Columns returns the column names of the row.
+func (r Row) Columns() []string {
+	return r.columns
+}
+
+// Values returns the column values of the row, in the order of the columns.
+func (r Row) Values() []any {
+	return r.values
+}
+
+// Map returns the row as a map from column names to values.
+func (r Row) Map() map[string]any {
+	m := make(map[string]any, len(r.columns))
+	for i, c := range r.columns {
+		m[c] = r.values[i]
+	}
+	return m
+}
+
+// Has reports if the row contains the given column.
+func (r Row) Has(column string) bool {
+	_, ok := r.index[column]
+	return ok
+}
+
+// IsNull reports if the value of the given column is NULL (or missing).
+func (r Row) IsNull(column string) bool {
+	return r.Get(column) == nil
+}
+
+// Get returns the value of the given column, or nil if it does not exist.
+func (r Row) Get(column string) any {
+	i, ok := r.index[column]
+	if !ok {
+		return nil
+	}
+	return r.values[i]
+}
+
+// GetString returns the value of the given column as a string.
+func (r Row) GetString(column string) (string, error) {
+	v, err := r.get(column)
+	if err != nil {
+		return "", err
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// GetInt64 returns the value of the given column as an int64.
+func (r Row) GetInt64(column string) (int64, error) {
+	v, err := r.get(column)
+	if err != nil {
+		return 0, err
+	}
+	switch v := v.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, fmt.Errorf("sql/row: column %q: %v is not an integer", column, v)
+		}
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	default:
+		return 0, fmt.Errorf("sql/row: column %q: unexpected type %T for int64", column, v)
+	}
+}
+
+// GetFloat64 returns the value of the given column as a float64.
+func (r Row) GetFloat64(column string) (float64, error) {
+	v, err := r.get(column)
+	if err != nil {
+		return 0, err
+	}
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case []byte:
+		return strconv.ParseFloat(string(v), 64)
+	default:
+		return 0, fmt.Errorf("sql/row: column %q: unexpected type %T for float64", column, v)
+	}
+}
+
+// GetBool returns the value of the given column as a bool.
+func (r Row) GetBool(column string) (bool, error) {
+	v, err := r.get(column)
+	if err != nil {
+		return false, err
+	}
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case int64:
+		return v != 0, nil
+	case string:
+		return strconv.ParseBool(v)
+	case []byte:
+		return strconv.ParseBool(string(v))
+	default:
+		return false, fmt.Errorf("sql/row: column %q: unexpected type %T for bool", column, v)
+	}
+}
+
+// timeLayouts are the layouts used for parsing textual time values.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// GetTime returns the value of the given column as a time.Time.
+// Textual values are parsed using the common database formats.
+func (r Row) GetTime(column string) (time.Time, error) {
+	v, err := r.get(column)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var s string
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return time.Time{}, fmt.Errorf("sql/row: column %q: unexpected type %T for time.Time", column, v)
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("sql/row: column %q: cannot parse %q as time.Time", column, s)
+}
+
+// GetBytes returns the value of the given column as a []byte.
+func (r Row) GetBytes(column string) ([]byte, error) {
+	v, err := r.get(column)
+	if err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, fmt.Errorf("sql/row: column %q: unexpected type %T for []byte", column, v)
+	}
+}
+
+// get returns the non-NULL value of the given column.
+func (r Row) get(column string) (any, error) {
+	i, ok := r.index[column]
+	if !ok {
+		return nil, fmt.Errorf("sql/row: missing column %q", column)
+	}
+	if r.values[i] == nil {
+		return nil, fmt.Errorf("sql/row: column %q is NULL", column)
+	}
+	return r.values[i], nil
+}
+
+// ScanRows scans all rows into dynamic Row values.
+func ScanRows(rows ColumnScanner) ([]Row, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		res   []Row
+		index = make(map[string]int, len(columns))
+		dest  = make([]any, len(columns))
+	)
+	for i, c := range columns {
+		// The first column wins in case of duplicate names.
+		if _, ok := index[c]; !ok {
+			index[c] = i
+		}
+	}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("sql/scan: failed scanning rows: %w", err)
+		}
+		normalizeValues(types, values)
+		res = append(res, Row{columns: columns, index: index, values: values})
+	}
+	return res, rows.Err()
+}
+
+// ScanMaps scans all rows into maps from column names to values.
+// Values are normalized as described in the Row type.
+func ScanMaps(rows ColumnScanner) ([]map[string]any, error) {
+	rs, err := ScanRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]map[string]any, len(rs))
+	for i := range rs {
+		res[i] = rs[i].Map()
+	}
+	return res, nil
+}
+
+// normalizeValues converts the []byte values of textual columns to strings.
+func normalizeValues(types []*sql.ColumnType, values []any) {
+	for i, v := range values {
+		if b, ok := v.([]byte); ok && i < len(types) && textual(types[i].DatabaseTypeName()) {
+			values[i] = string(b)
+		}
+	}
+}
+
+// textual reports if the given database type name is a textual (or
+// a textual representation of a) type, rather than a binary type.
+// An unknown (empty) type name is not considered textual.
+func textual(name string) bool {
+	name = strings.ToUpper(name)
+	switch {
+	case name == "":
+		return false
+	case strings.Contains(name, "BLOB"), strings.Contains(name, "BINARY"),
+		strings.Contains(name, "BYTEA"), name == "BIT", name == "GEOMETRY":
+		return false
+	}
+	return true
+}
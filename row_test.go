@@ -0,0 +1,51 @@
+package duo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanRows(t *testing.T) {
+	now := time.Now()
+	mock := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("BIGINT", int64(0)),
+		sqlmock.NewColumn("name").OfType("VARCHAR", ""),
+		sqlmock.NewColumn("avatar").OfType("BLOB", []byte(nil)),
+		sqlmock.NewColumn("created").OfType("DATETIME", now),
+		sqlmock.NewColumn("deleted").OfType("DATETIME", now),
+	).
+		AddRow(1, []byte("a8m"), []byte{1, 2}, []byte("2022-01-02 10:11:12"), nil)
+	rows, err := ScanRows(toRows(mock))
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	r := rows[0]
+	assert.Equal(t, []string{"id", "name", "avatar", "created", "deleted"}, r.Columns())
+	assert.Equal(t, "a8m", r.Get("name"), "text columns are normalized")
+	assert.Equal(t, []byte{1, 2}, r.Get("avatar"), "binary columns are kept")
+	assert.True(t, r.Has("deleted"))
+	assert.True(t, r.IsNull("deleted"))
+	assert.False(t, r.Has("age"))
+
+	id, err := r.GetInt64("id")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+	name, err := r.GetString("name")
+	require.NoError(t, err)
+	assert.Equal(t, "a8m", name)
+	created, err := r.GetTime("created")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 1, 2, 10, 11, 12, 0, time.UTC), created)
+	_, err = r.GetTime("deleted")
+	assert.EqualError(t, err, `sql/row: column "deleted" is NULL`)
+	_, err = r.GetString("age")
+	assert.EqualError(t, err, `sql/row: missing column "age"`)
+
+	mock = sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(2, "bar")
+	maps, err := ScanMaps(toRows(mock))
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"id": int64(1), "name": "foo"}, {"id": int64(2), "name": "bar"}}, maps)
+}
@@ -1,22 +1,134 @@
 package duo
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
 
 	"github.com/modern-go/reflect2"
 )
 
-func ScanOne(rows ColumnScanner, v any) error {
+type (
+	// scanConfig holds the configuration of the scanning functions.
+	scanConfig struct {
+		unknown unknownPolicy
+		naming  *NamingStrategy
+		// strictNull fails scanning NULL values into non-nullable fields.
+		strictNull bool
+		// run holds the options that do not affect the column mapping.
+		run runConfig
+		// types holds the database type names of the columns, joined
+		// by a NUL character. Set only if they are used by converters.
+		types string
+	}
+
+	// runConfig holds the scan options that apply to a single scan call, and
+	// are not part of the compiled mapping (and the cache key) of a type.
+	runConfig struct {
+		// capacity of the result.
+		capacity int
+		// checkEvery, maxRows and maxBytes are the
+		// cancellation checks and limits of the scan.
+		checkEvery, maxRows int
+		maxBytes            int64
+	}
+
+	// ScanOption allows configuring the scanning
+	// functions using functional options.
+	ScanOption func(*scanConfig)
+
+	// unknownPolicy defines how columns that are
+	// not mapped to struct fields are handled.
+	unknownPolicy uint8
+)
+
+const (
+	unknownFail unknownPolicy = iota
+	unknownIgnore
+	unknownCollect
+)
+
+// Strict fails the scan if the result set contains a column
+// that is not mapped to a struct field. This is the default.
+func Strict() ScanOption {
+	return func(c *scanConfig) {
+		c.unknown = unknownFail
+	}
+}
+
+// IgnoreUnknown skips columns that are not mapped to struct fields.
+// It is useful for scanning `SELECT *` queries into structs that
+// do not declare all columns of the table.
+func IgnoreUnknown() ScanOption {
+	return func(c *scanConfig) {
+		c.unknown = unknownIgnore
+	}
+}
+
+// CollectUnknown collects the columns that are not mapped to struct fields
+// into a map[string]any field tagged with `sql:",extra"`. For example:
+//
+//	type User struct {
+//		ID    int
+//		Name  string
+//		Extra map[string]any `sql:",extra"`
+//	}
+func CollectUnknown() ScanOption {
+	return func(c *scanConfig) {
+		c.unknown = unknownCollect
+	}
+}
+
+// Capacity pre-allocates the result of the scan for n rows. It is
+// useful when the number of rows is known in advance (e.g. from a LIMIT).
+func Capacity(n int) ScanOption {
+	return func(c *scanConfig) {
+		c.run.capacity = n
+	}
+}
+
+// newScanConfig returns the scan configuration for the given options.
+func newScanConfig(opts []ScanOption) scanConfig {
+	var cfg scanConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.naming == nil {
+		cfg.naming = DefaultNaming()
+	}
+	return cfg
+}
+
+// ScanOne scans exactly one row into v. If v is a pointer to a struct, the
+// columns are mapped to its fields as in ScanSlice. Otherwise, the result set
+// is expected to contain exactly one column.
+func ScanOne(rows ColumnScanner, v any, opts ...ScanOption) error {
 	columns, err := rows.Columns()
 	if err != nil {
 		return fmt.Errorf("sql/scan: failed getting column names: %w", err)
 	}
 
-	if n := len(columns); n != 1 {
+	var scan *rowScan
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct && !scannable(rv.Elem().Type()) {
+		cfg := newScanConfig(opts)
+		if cfg.types, err = columnTypes(rows); err != nil {
+			return err
+		}
+		if scan, err = scanType(rv.Elem().Type(), columns, cfg); err != nil {
+			return err
+		}
+		if err := validateColumns(rows, scan); err != nil {
+			return err
+		}
+	} else if n := len(columns); n != 1 {
 		return fmt.Errorf("sql/scan: unexpected number of columns: %d", n)
 	}
 
@@ -27,8 +139,20 @@ func ScanOne(rows ColumnScanner, v any) error {
 		return sql.ErrNoRows
 	}
 
-	if err := rows.Scan(v); err != nil {
-		return err
+	if scan == nil {
+		if err := rows.Scan(v); err != nil {
+			return scanFailed(rows, nil, 0, err, v)
+		}
+	} else {
+		values := scan.values()
+		if err := rows.Scan(values...); err != nil {
+			return scanFailed(rows, scan, 0, err, values...)
+		}
+		rv, err := scan.value(reflect.TypeOf(v).Elem(), values...)
+		if err != nil {
+			return scanFailed(rows, scan, 0, err)
+		}
+		reflect.ValueOf(v).Elem().Set(rv)
 	}
 
 	if rows.Next() {
@@ -37,9 +161,9 @@ func ScanOne(rows ColumnScanner, v any) error {
 	return nil
 }
 
-func Scan[T any](rows ColumnScanner) (T, error) {
+func Scan[T any](rows ColumnScanner, opts ...ScanOption) (T, error) {
 	var n T
-	if err := ScanOne(rows, &n); err != nil {
+	if err := ScanOne(rows, &n, opts...); err != nil {
 		return n, err
 	}
 	return n, nil
@@ -53,42 +177,182 @@ func ScanValue(rows ColumnScanner) (driver.Value, error) {
 	return v, nil
 }
 
-func ScanSlice[T any](rows ColumnScanner) ([]T, error) {
+func ScanSlice[T any](rows ColumnScanner, opts ...ScanOption) ([]T, error) {
+	return ScanSliceContext[T](context.Background(), rows, opts...)
+}
 
-	columns, err := rows.Columns()
+// ScanSliceContext is like ScanSlice, but it checks the context cancellation
+// between rows (see CheckEvery), and stops with the context error once it is
+// canceled. Use the MaxRows and MaxBytes options to protect against unbounded
+// result sets. For example:
+//
+//	users, err := duo.ScanSliceContext[User](ctx, rows, duo.MaxRows(10000))
+//	if errors.As(err, new(*duo.ResultTooLargeError)) {
+//		...
+//	}
+func ScanSliceContext[T any](ctx context.Context, rows ColumnScanner, opts ...ScanOption) ([]T, error) {
+	cfg := newScanConfig(opts)
+	scan, err := scanRows[T](rows, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	var t T
-	e := reflect.ValueOf(reflect2.TypeOf(t).New()).Elem()
-	scan, err := scanType(e.Type(), columns)
-	if err != nil {
-		return nil, err
+	var (
+		res    []T
+		zero   T
+		values = scan.values()
+		limit  = newScanLimiter(ctx, cfg.run)
+	)
+	if cfg.run.capacity > 0 {
+		res = make([]T, 0, cfg.run.capacity)
 	}
-	if n, m := len(columns), len(scan.columns); n > m {
-		return nil, fmt.Errorf("sql/scan: columns do not match (%d > %d)", n, m)
+	for {
+		if err := limit.before(); err != nil {
+			return nil, err
+		}
+		if !rows.Next() {
+			break
+		}
+		if err := rows.Scan(values...); err != nil {
+			return nil, scanFailed(rows, scan, len(res), err, values...)
+		}
+		if err := limit.after(values); err != nil {
+			return nil, err
+		}
+		// Scan the row directly into the slice to avoid copying.
+		res = append(res, zero)
+		if err := scan.assign(reflect.ValueOf(&res[len(res)-1]).Elem(), values...); err != nil {
+			return nil, scanFailed(rows, scan, len(res)-1, err)
+		}
 	}
 
-	var res []T
-	for rows.Next() {
-		values := scan.values()
+	return res, rows.Err()
+}
+
+// ScanSets scans successive result sets of rows into the given targets, one result
+// set per target. A target that is a pointer to a slice is filled with all rows of
+// its result set (as in ScanSlice), and any other pointer is scanned from exactly
+// one row (as in ScanOne). ScanOption values can be passed among the targets, and
+// they are applied to all result sets. It is useful for stored procedures and
+// multi-statement batches that return more than one result set. For example:
+//
+//	var (
+//		users []User
+//		total int
+//	)
+//	if err := duo.ScanSets(rows, &users, &total, duo.IgnoreUnknown()); err != nil {
+//		return err
+//	}
+func ScanSets(rows ColumnScanner, targets ...any) error {
+	var opts []ScanOption
+	for i := 0; i < len(targets); i++ {
+		if opt, ok := targets[i].(ScanOption); ok {
+			opts = append(opts, opt)
+			targets = append(targets[:i:i], targets[i+1:]...)
+			i--
+		}
+	}
+	for i, target := range targets {
+		if i > 0 && !rows.NextResultSet() {
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("sql/scan: expect %d result sets, got %d", len(targets), i)
+		}
+		rv := reflect.ValueOf(target)
+		if rv.Kind() != reflect.Ptr || rv.IsNil() {
+			return fmt.Errorf("sql/scan: invalid type %T for result set %d. expect a non-nil pointer", target, i)
+		}
+		var err error
+		if rv.Elem().Kind() == reflect.Slice && !assignable(rv.Elem().Type()) {
+			err = scanSlice(rows, rv.Elem(), newScanConfig(opts))
+		} else {
+			err = ScanOne(rows, target, opts...)
+		}
+		if err != nil {
+			return fmt.Errorf("sql/scan: result set %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// scanSlice scans the rows of the current result set and appends them to the given
+// slice value. It is the reflect-based version of ScanSlice for non-generic callers.
+func scanSlice(rows ColumnScanner, slice reflect.Value, cfg scanConfig) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if cfg.types, err = columnTypes(rows); err != nil {
+		return err
+	}
+	typ := slice.Type().Elem()
+	scan, err := scanType(typ, columns, cfg)
+	if err != nil {
+		return err
+	}
+	if err := validateColumns(rows, scan); err != nil {
+		return err
+	}
+	var (
+		values = scan.values()
+		limit  = newScanLimiter(context.Background(), cfg.run)
+	)
+	for i := 0; rows.Next(); i++ {
 		if err := rows.Scan(values...); err != nil {
-			return nil, fmt.Errorf("sql/scan: failed scanning rows: %w", err)
+			return scanFailed(rows, scan, i, err, values...)
 		}
-		res = append(res, scan.value(values...).Interface().(T))
+		if err := limit.after(values); err != nil {
+			return err
+		}
+		slice.Set(reflect.Append(slice, reflect.Zero(typ)))
+		if err := scan.assign(slice.Index(slice.Len()-1), values...); err != nil {
+			return scanFailed(rows, scan, i, err)
+		}
+	}
+	return rows.Err()
+}
+
+// scanRows returns the rowScan of T for the columns of the given rows.
+func scanRows[T any](rows ColumnScanner, cfg scanConfig) (*rowScan, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
 	}
 
-	return res, rows.Err()
+	if cfg.types, err = columnTypes(rows); err != nil {
+		return nil, err
+	}
+
+	var t T
+	e := reflect.ValueOf(reflect2.TypeOf(t).New()).Elem()
+	scan, err := scanType(e.Type(), columns, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateColumns(rows, scan); err != nil {
+		return nil, err
+	}
+	return scan, nil
 }
 
+// rowScan is a compiled mapping from the columns of a result set to a Go type.
+// It is immutable once compiled, and it is shared between scans using scanCache.
 type rowScan struct {
 	// column types of a row.
 	columns []reflect.Type
-	// value functions that converts the row columns (result) to a reflect.Value.
-	value func(v ...any) reflect.Value
+	// fields describes the destinations of the columns, for diagnostics.
+	fields []columnField
+	// assign sets the row columns (result) to the given addressable value. The
+	// value is expected to be zeroed, as NULL columns are not assigned.
+	assign func(dst reflect.Value, v ...any) error
 }
 
+// values returns the scan destinations of a row. They can be reused for all rows,
+// as assign copies the scanned data out of them, and it resets the holders of
+// Scanner and reference types (e.g. maps) that would otherwise share their state
+// with the previous rows. sql.RawBytes destinations are the exception, and they
+// hold memory of the driver that is valid until the next call to Next.
 func (r *rowScan) values() []any {
 	values := make([]any, len(r.columns))
 	for i := range r.columns {
@@ -97,26 +361,164 @@ func (r *rowScan) values() []any {
 	return values
 }
 
+// reset zeroes the given scan destinations of a row that is not assigned (e.g. a
+// repeated row of ScanGraph), as the holders are otherwise reset only by assign.
+func (r *rowScan) reset(values []any) {
+	for _, v := range values {
+		rv := reflect.ValueOf(v).Elem()
+		rv.Set(reflect.Zero(rv.Type()))
+	}
+}
+
+// value returns a new value of type typ from the row columns.
+func (r *rowScan) value(typ reflect.Type, vs ...any) (reflect.Value, error) {
+	v := reflect.New(typ).Elem()
+	if err := r.assign(v, vs...); err != nil {
+		return reflect.Value{}, err
+	}
+	return v, nil
+}
+
+type scanKey struct {
+	typ     reflect.Type
+	columns string
+	cfg     scanConfig
+	// naming holds the state of the naming strategy of cfg.
+	naming namingKey
+	// version of the converters registry.
+	version uint64
+}
+
+// scanCacheSize bounds the number of entries in the scan cache. The key includes
+// the column list, and applications that build queries with dynamic selections may
+// produce an unbounded number of keys. The cache is cleared once it is full, as
+// recompiling a rowScan is cheap compared to the query that it scans.
+const scanCacheSize = 1 << 12
+
+var (
+	// scanCache caches the compiled rowScan of (type, columns, config) triplets.
+	scanCache sync.Map
+	// scanCacheLen is the approximate number of entries in scanCache.
+	scanCacheLen int64
+)
+
 // scanType returns rowScan for the given reflect.Type.
-func scanType(typ reflect.Type, columns []string) (*rowScan, error) {
+func scanType(typ reflect.Type, columns []string, cfg scanConfig) (*rowScan, error) {
+	cfg.run = runConfig{}
+	key := scanKey{typ: typ, columns: strings.Join(columns, "\x00"), cfg: cfg, naming: cfg.naming.key(), version: convertersVersion()}
+	if scan, ok := scanCache.Load(key); ok {
+		return scan.(*rowScan), nil
+	}
+	scan, err := compileType(typ, columns, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if n, m := len(columns), len(scan.columns); n > m {
+		if cfg.unknown != unknownIgnore {
+			return nil, fmt.Errorf("sql/scan: columns do not match (%d > %d)", n, m)
+		}
+		// Discard the rest of the columns.
+		for ; m < n; m++ {
+			scan.columns = append(scan.columns, anyType)
+		}
+	}
+	actual, loaded := scanCache.LoadOrStore(key, scan)
+	if !loaded && atomic.AddInt64(&scanCacheLen, 1) > scanCacheSize {
+		atomic.StoreInt64(&scanCacheLen, 0)
+		scanCache.Range(func(k, _ any) bool {
+			scanCache.Delete(k)
+			return true
+		})
+	}
+	return actual.(*rowScan), nil
+}
+
+// compileType compiles the rowScan for the given reflect.Type.
+func compileType(typ reflect.Type, columns []string, cfg scanConfig) (*rowScan, error) {
+	// Types other than structs are scanned from a single column.
+	if st := indirect(typ); len(columns) == 0 && (st.Kind() != reflect.Struct || scannable(st) || hasConverter(typ) || hasConverter(st)) {
+		return nil, fmt.Errorf("sql/scan: missing column for type %s", typ)
+	}
+	types := strings.Split(cfg.types, "\x00")
+	if c := lookupConverter(typ, types[0]); c != nil {
+		rtype, set := scanConverter(c, func(dst reflect.Value) unsafe.Pointer {
+			return dst.Addr().UnsafePointer()
+		}, cfg.strictNull && !nillable(typ))
+		f := columnField{column: columns[0], path: typeName(typ)}
+		return &rowScan{
+			columns: []reflect.Type{rtype},
+			fields:  []columnField{f},
+			assign: func(dst reflect.Value, v ...any) error {
+				if err := set(dst, v[0]); err != nil {
+					return fieldError(f, v[0], err)
+				}
+				return nil
+			},
+		}, nil
+	}
 	switch k := typ.Kind(); {
 	case assignable(typ):
+		var (
+			t2    = reflect2.Type2(typ)
+			zero  = t2.UnsafeNew()
+			reset = typ.Implements(scannerType)
+			f     = columnField{column: columns[0], path: typeName(typ)}
+		)
+		if !reset {
+			f.typ = typ
+		}
+		return &rowScan{
+			columns: []reflect.Type{typ},
+			fields:  []columnField{f},
+			assign: func(dst reflect.Value, v ...any) error {
+				p := reflect2.PtrOf(v[0])
+				t2.UnsafeSet(dst.Addr().UnsafePointer(), p)
+				// Scanner holders are reset as they may share state between rows.
+				if reset {
+					t2.UnsafeSet(p, zero)
+				}
+				return nil
+			},
+		}, nil
+	// Value types that implement sql.Scanner using a pointer receiver (e.g. Null[T] or
+	// sql.NullString) are scanned into reusable holders that are reset after each row.
+	case k != reflect.Ptr && reflect.PtrTo(typ).Implements(scannerType):
+		var (
+			t2   = reflect2.Type2(typ)
+			zero = t2.UnsafeNew()
+		)
 		return &rowScan{
 			columns: []reflect.Type{typ},
-			value: func(v ...any) reflect.Value {
-				return reflect.Indirect(reflect.ValueOf(v[0]))
+			fields:  []columnField{{column: columns[0], path: typeName(typ)}},
+			assign: func(dst reflect.Value, v ...any) error {
+				p := reflect2.PtrOf(v[0])
+				t2.UnsafeSet(dst.Addr().UnsafePointer(), p)
+				t2.UnsafeSet(p, zero)
+				return nil
+			},
+		}, nil
+	case arrayType(typ):
+		return &rowScan{
+			columns: []reflect.Type{anyType},
+			fields:  []columnField{{column: columns[0], path: typeName(typ)}},
+			assign: func(dst reflect.Value, v ...any) error {
+				return scanArray(*v[0].(*any), dst, cfg.strictNull)
 			},
 		}, nil
 	case k == reflect.Ptr:
-		return scanPtr(typ, columns)
+		return scanPtr(typ, columns, cfg)
 	case k == reflect.Struct:
-		return scanStruct(typ, columns)
+		return scanStruct(typ, columns, cfg)
 	default:
 		return nil, fmt.Errorf("sql/scan: unsupported type ([]%s)", k)
 	}
 }
 
-var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	anyType     = reflect.TypeOf((*any)(nil)).Elem()
+	extraType   = reflect.TypeOf(map[string]any(nil))
+)
 
 func assignable(typ reflect.Type) bool {
 	switch k := typ.Kind(); {
@@ -130,93 +532,533 @@ func assignable(typ reflect.Type) bool {
 	return true
 }
 
-func scanPtr(typ reflect.Type, columns []string) (*rowScan, error) {
+func scanPtr(typ reflect.Type, columns []string, cfg scanConfig) (*rowScan, error) {
 	typ = typ.Elem()
-	scan, err := scanType(typ, columns)
+	scan, err := scanType(typ, columns, cfg)
 	if err != nil {
 		return nil, err
 	}
-	wrap := scan.value
-	scan.value = func(vs ...any) reflect.Value {
-		v := wrap(vs...)
-		pt := reflect.PtrTo(v.Type())
-		pv := reflect.New(pt.Elem())
-		pv.Elem().Set(v)
-		return pv
-	}
-	return scan, nil
+	return &rowScan{
+		columns: scan.columns,
+		fields:  scan.fields,
+		assign: func(dst reflect.Value, vs ...any) error {
+			pv := reflect.New(typ)
+			if err := scan.assign(pv.Elem(), vs...); err != nil {
+				return err
+			}
+			dst.Set(pv)
+			return nil
+		},
+	}, nil
 }
 
-func scanStruct(typ reflect.Type, columns []string) (*rowScan, error) {
+func scanStruct(typ reflect.Type, columns []string, cfg scanConfig) (*rowScan, error) {
 	var (
 		scan  = &rowScan{}
-		idxs  = make([][]int, 0, typ.NumField())
-		names = make(map[string][]int, typ.NumField())
+		sets  = make([]func(reflect.Value, any) error, 0, len(columns))
+		names = make(map[string]structField)
+		extra []int
+		types = strings.Split(cfg.types, "\x00")
 	)
-	for i := 0; i < typ.NumField(); i++ {
-		f := typ.Field(i)
-		// Skip unexported fields.
-		if f.PkgPath != "" {
-			continue
-		}
-		// Support 1-level embedding to accepts types as `type T struct {ent.T; V int}`.
-		if typ := f.Type; f.Anonymous && typ.Kind() == reflect.Struct {
-			for j := 0; j < typ.NumField(); j++ {
-				names[columnName(typ.Field(j))] = []int{i, j}
+	for _, f := range structFields(typ, cfg.naming) {
+		if f.opts.Has("extra") {
+			extra = f.index
+			if f.typ != extraType {
+				return nil, fmt.Errorf("sql/scan: extra field %s must be of type map[string]any", f.typ)
 			}
 			continue
 		}
-		names[columnName(f)] = []int{i}
+		names[cfg.naming.normalize(f.name)] = f
 	}
-	for _, c := range columns {
+	if cfg.unknown == unknownCollect && extra == nil {
+		return nil, fmt.Errorf("sql/scan: missing extra field (`sql:\",extra\"`) in %s", typ)
+	}
+	for i, c := range columns {
 		// Normalize columns if necessary, for example: COUNT(*) => count.
-		name := strings.ToLower(strings.Split(c, "(")[0])
-		idx, ok := names[name]
+		name := cfg.naming.normalize(c)
+		f, ok := names[name]
 		if !ok {
-			return nil, fmt.Errorf("sql/scan: missing struct field for column: %s (%s)", c, name)
-		}
-		idxs = append(idxs, idx)
-		rtype := typ.Field(idx[0]).Type
-		if len(idx) > 1 {
-			rtype = rtype.Field(idx[1]).Type
+			if cfg.unknown == unknownFail {
+				return nil, fmt.Errorf("sql/scan: missing struct field for column: %s (%s)", c, name)
+			}
+			// Unknown columns are scanned into interface values,
+			// and are either discarded or collected into the extra field.
+			scan.columns = append(scan.columns, anyType)
+			scan.fields = append(scan.fields, columnField{column: c})
+			sets = append(sets, collectColumn(cfg, extra, columns[i]))
+			continue
 		}
-		if !nillable(rtype) {
-			// Create a pointer to the actual reflect
-			// types to accept optional struct fields.
-			rtype = reflect.PtrTo(rtype)
+		var dbType string
+		if i < len(types) {
+			dbType = types[i]
 		}
+		// Fields of optional (pointer) nested structs are nullable, as all
+		// their columns are NULL if the nested struct is missing.
+		rtype, set := scanField(typ, f, dbType, cfg.strictNull && !optionalField(typ, f.index))
+		cf := columnField{column: c, path: fieldPath(typ, f.index), typ: checkedType(f, dbType)}
 		scan.columns = append(scan.columns, rtype)
+		scan.fields = append(scan.fields, cf)
+		sets = append(sets, set)
 	}
-	scan.value = func(vs ...any) reflect.Value {
-		st := reflect.New(typ).Elem()
+	scan.assign = func(st reflect.Value, vs ...any) error {
 		for i, v := range vs {
-			rv := reflect.Indirect(reflect.ValueOf(v))
-			if rv.IsNil() {
+			if err := sets[i](st, v); err != nil {
+				return fieldError(scan.fields[i], v, err)
+			}
+		}
+		return nil
+	}
+	return scan, nil
+}
+
+// checkedType returns the field type that is checked by validateColumns against
+// the column type, or nil if the field decodes the column itself (e.g. using a
+// converter, a JSON decoder or a sql.Scanner).
+func checkedType(f structField, dbType string) reflect.Type {
+	if f.opts.Has("json") || reflect.PtrTo(f.typ).Implements(scannerType) || lookupConverter(f.typ, dbType) != nil || ptrConverter(f.typ, dbType) != nil {
+		return nil
+	}
+	return f.typ
+}
+
+// collectColumn returns the function for assigning an unknown column to the extra field.
+func collectColumn(cfg scanConfig, extra []int, column string) func(reflect.Value, any) error {
+	return func(st reflect.Value, v any) error {
+		if cfg.unknown != unknownCollect {
+			return nil
+		}
+		m := fieldByIndex(st, extra)
+		if m.IsNil() {
+			m.Set(reflect.MakeMap(extraType))
+		}
+		m.SetMapIndex(reflect.ValueOf(column), reflect.ValueOf(v).Elem())
+		return nil
+	}
+}
+
+// scanField returns the scan destination type of the given field, and the function
+// for assigning the scanned value to the field. Fields of basic types are scanned
+// into reusable sql.Null* values to avoid allocations, and are copied directly
+// to their address in the struct using the reflect2 unsafe accessors.
+func scanField(typ reflect.Type, f structField, dbType string, strict bool) (reflect.Type, func(reflect.Value, any) error) {
+	var (
+		t2     = reflect2.Type2(f.typ)
+		target = fieldTarget(typ, f)
+	)
+	if c := lookupConverter(f.typ, dbType); c != nil && !f.opts.Has("json") {
+		return scanConverter(c, target, strict && !nillable(f.typ))
+	}
+	// Pointers to converted types are allocated for non-NULL values.
+	if c := ptrConverter(f.typ, dbType); c != nil && !f.opts.Has("json") {
+		elem := f.typ.Elem()
+		return scanConverter(c, func(st reflect.Value) unsafe.Pointer {
+			p := reflect.New(elem).UnsafePointer()
+			*(*unsafe.Pointer)(target(st)) = p
+			return p
+		}, false)
+	}
+	// Fields tagged with the "json" option are decoded from the
+	// raw column value (JSON, JSONB or TEXT) into their Go type.
+	if f.opts.Has("json") {
+		return reflect.TypeOf(sql.RawBytes{}), func(st reflect.Value, v any) error {
+			raw := *v.(*sql.RawBytes)
+			if raw == nil {
+				if strict && !nillable(f.typ) {
+					return ErrUnexpectedNull
+				}
+				return nil
+			}
+			if err := json.Unmarshal(raw, reflect.NewAt(f.typ, target(st)).Interface()); err != nil {
+				return fmt.Errorf("sql/scan: unmarshal json: %w", err)
+			}
+			return nil
+		}
+	}
+	// Slices of basic types are decoded from array columns.
+	if arrayType(f.typ) {
+		return anyType, func(st reflect.Value, v any) error {
+			// NULL arrays are left nil, without resolving (and allocating) the target.
+			if src := *v.(*any); src != nil {
+				return scanArray(src, reflect.NewAt(f.typ, target(st)).Elem(), strict)
+			}
+			return nil
+		}
+	}
+	// Null[T] fields are scanned directly into reusable holders of their type.
+	if f.typ.Implements(nullType) {
+		valid, _ := f.typ.FieldByName("Valid")
+		return f.typ, func(st reflect.Value, v any) error {
+			// NULL values are left unset, without resolving (and allocating) the target.
+			if p := reflect2.PtrOf(v); *(*bool)(unsafe.Add(p, valid.Offset)) {
+				t2.UnsafeSet(target(st), p)
+			}
+			return nil
+		}
+	}
+	if !nillable(f.typ) && !reflect.PtrTo(f.typ).Implements(scannerType) {
+		switch k := f.typ.Kind(); {
+		case f.typ == timeType:
+			return reflect.TypeOf(sql.NullTime{}), func(st reflect.Value, v any) error {
+				if n := v.(*sql.NullTime); n.Valid {
+					*(*time.Time)(target(st)) = n.Time
+				} else if strict {
+					return ErrUnexpectedNull
+				}
+				return nil
+			}
+		case k == reflect.String:
+			return reflect.TypeOf(sql.NullString{}), func(st reflect.Value, v any) error {
+				if n := v.(*sql.NullString); n.Valid {
+					*(*string)(target(st)) = n.String
+				} else if strict {
+					return ErrUnexpectedNull
+				}
+				return nil
+			}
+		case k == reflect.Bool:
+			return reflect.TypeOf(sql.NullBool{}), func(st reflect.Value, v any) error {
+				if n := v.(*sql.NullBool); n.Valid {
+					*(*bool)(target(st)) = n.Bool
+				} else if strict {
+					return ErrUnexpectedNull
+				}
+				return nil
+			}
+		case k == reflect.Float64:
+			return reflect.TypeOf(sql.NullFloat64{}), func(st reflect.Value, v any) error {
+				if n := v.(*sql.NullFloat64); n.Valid {
+					*(*float64)(target(st)) = n.Float64
+				} else if strict {
+					return ErrUnexpectedNull
+				}
+				return nil
+			}
+		case k >= reflect.Int && k <= reflect.Int64:
+			zero := reflect.Zero(f.typ)
+			return reflect.TypeOf(sql.NullInt64{}), func(st reflect.Value, v any) error {
+				n := v.(*sql.NullInt64)
+				if !n.Valid {
+					if strict {
+						return ErrUnexpectedNull
+					}
+					return nil
+				}
+				if zero.OverflowInt(n.Int64) {
+					return fmt.Errorf("sql/scan: value %d overflows %s", n.Int64, f.typ)
+				}
+				setInt(target(st), k, n.Int64)
+				return nil
+			}
+		}
+	}
+	// Nillable fields are scanned into holders of their type that are reset
+	// after each row, as Scanner implementations (e.g. of map or slice types)
+	// may fill the existing value or leave it unchanged on NULL.
+	if nillable(f.typ) {
+		zero := t2.UnsafeNew()
+		return f.typ, func(st reflect.Value, v any) error {
+			if p := reflect2.PtrOf(v); !t2.UnsafeIsNil(p) {
+				t2.UnsafeSet(target(st), p)
+				t2.UnsafeSet(p, zero)
+			}
+			return nil
+		}
+	}
+	// Create a pointer to the actual reflect
+	// types to accept optional struct fields.
+	strict = strict && !reflect.PtrTo(f.typ).Implements(scannerType)
+	return reflect.PtrTo(f.typ), func(st reflect.Value, v any) error {
+		if p := *(*unsafe.Pointer)(reflect2.PtrOf(v)); p != nil {
+			t2.UnsafeSet(target(st), p)
+		} else if strict {
+			return ErrUnexpectedNull
+		}
+		return nil
+	}
+}
+
+// optionalField reports if the path to the field at the given index goes through a pointer.
+func optionalField(typ reflect.Type, index []int) bool {
+	for i := 0; i < len(index)-1; i++ {
+		if typ = typ.Field(index[i]).Type; typ.Kind() == reflect.Ptr {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldTarget returns a function that returns the address of the field in
+// the given struct. Fields of embedded (or nested) structs are resolved by
+// their offset, unless the path to the field goes through a pointer.
+func fieldTarget(typ reflect.Type, f structField) func(reflect.Value) unsafe.Pointer {
+	var off uintptr
+	for i, t := 0, typ; i < len(f.index); i++ {
+		sf := t.Field(f.index[i])
+		if i < len(f.index)-1 && sf.Type.Kind() == reflect.Ptr {
+			return func(st reflect.Value) unsafe.Pointer {
+				return fieldByIndex(st, f.index).Addr().UnsafePointer()
+			}
+		}
+		off += sf.Offset
+		t = sf.Type
+	}
+	return func(st reflect.Value) unsafe.Pointer {
+		return unsafe.Add(st.Addr().UnsafePointer(), off)
+	}
+}
+
+// setInt writes the given int64 to the integer of kind k pointed by p.
+func setInt(p unsafe.Pointer, k reflect.Kind, n int64) {
+	switch k {
+	case reflect.Int:
+		*(*int)(p) = int(n)
+	case reflect.Int8:
+		*(*int8)(p) = int8(n)
+	case reflect.Int16:
+		*(*int16)(p) = int16(n)
+	case reflect.Int32:
+		*(*int32)(p) = int32(n)
+	case reflect.Int64:
+		*(*int64)(p) = n
+	}
+}
+
+// structField describes a struct field that is mapped to a column.
+type structField struct {
+	// name of the column.
+	name string
+	// index sequence of the field (see reflect.Value.FieldByIndex).
+	index []int
+	// typ of the field.
+	typ reflect.Type
+	// tag options of the field.
+	opts tagOptions
+	// nested reports if the field belongs to a nested (non-embedded) struct.
+	nested bool
+	// dbTag reports if the field has a tag of the database
+	// key (the first key of the naming strategy).
+	dbTag bool
+}
+
+// structFields returns the fields of the given struct type that are mapped to
+// columns. Fields of embedded structs are flattened, and fields of nested struct
+// fields (or pointers to structs) are prefixed with the "prefix" option of their
+// tag, or with their column name followed by a dot. For example:
+//
+//	type T struct {
+//		Base                              // id, created_at
+//		Owner  User                       // owner.id, owner.name
+//		Group  *Group `sql:"g,prefix=g_"` // g_id, g_name
+//	}
+//
+// Similar to Go visibility rules, a field with a shallower depth
+// hides fields with the same column name in deeper levels.
+func structFields(typ reflect.Type, n *NamingStrategy) []structField {
+	var (
+		fields []structField
+		depths = make(map[string]int)
+		walk   func(reflect.Type, []int, string, bool)
+	)
+	walk = func(typ reflect.Type, index []int, prefix string, nested bool) {
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			// Skip unexported fields.
+			if f.PkgPath != "" {
 				continue
 			}
-			idx := idxs[i]
-			rvalue := st.Field(idx[0])
-			if len(idx) > 1 {
-				rvalue = rvalue.Field(idx[1])
+			name, opts, tagged := n.parseTag(f)
+			if name == "-" {
+				continue
 			}
-			if !nillable(rvalue.Type()) {
-				rv = reflect.Indirect(rv)
+			idx := append(append(make([]int, 0, len(index)+1), index...), i)
+			if st := indirect(f.Type); st.Kind() == reflect.Struct && !scannable(st) && !opts.Has("json") && !hasConverter(f.Type) && !hasConverter(st) {
+				p, hasPrefix := opts.Value("prefix")
+				switch {
+				// Embedded structs without an explicit column name or prefix are
+				// flattened to accept types as `type T struct {ent.T; V int}`.
+				case f.Anonymous && !tagged && !hasPrefix:
+					walk(st, idx, prefix, nested)
+				case hasPrefix:
+					walk(st, idx, prefix+p, true)
+				default:
+					walk(st, idx, prefix+name+".", true)
+				}
+				continue
 			}
-			rvalue.Set(rv)
+			name = prefix + name
+			if d, ok := depths[name]; ok && d <= len(idx) {
+				continue
+			}
+			depths[name] = len(idx)
+			for j := range fields {
+				if fields[j].name == name {
+					fields = append(fields[:j], fields[j+1:]...)
+					break
+				}
+			}
+			_, dbTag := f.Tag.Lookup(n.tags()[0])
+			fields = append(fields, structField{name: name, index: idx, typ: f.Type, opts: opts, nested: nested, dbTag: dbTag})
 		}
-		return st
 	}
-	return scan, nil
+	walk(typ, nil, "", false)
+	return fields
+}
+
+// primaryKey returns the primary key fields of a struct: the fields that are
+// tagged with the "pk" option, or the "id" field if no field is tagged.
+func primaryKey(fields []structField, n *NamingStrategy) []structField {
+	var (
+		pk []structField
+		id []structField
+	)
+	for _, f := range fields {
+		switch {
+		case f.opts.Has("pk"):
+			pk = append(pk, f)
+		case n.normalize(f.name) == "id":
+			id = append(id, f)
+		}
+	}
+	if len(pk) == 0 {
+		return id
+	}
+	return pk
+}
+
+// valueFields returns the fields of the given struct type that hold column
+// values. Fields of nested structs and the extra field are skipped.
+func valueFields(typ reflect.Type, n *NamingStrategy) []structField {
+	var fields []structField
+	for _, f := range structFields(typ, n) {
+		if !f.nested && !f.opts.Has("extra") {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// omitZero reports if the given field is omitted from INSERT and UPDATE statements
+// when it holds the zero value: fields with the "omitempty" or the "auto" options
+// in their database tag. The options of other tag keys (e.g. json) are ignored, as
+// they configure the encoding of these keys.
+func omitZero(f structField) bool {
+	return f.dbTag && (f.opts.Has("omitempty") || f.opts.Has("auto"))
+}
+
+// structValues returns the columns and the values of the given struct value,
+// as used for building INSERT and UPDATE statements. Fields of nested structs
+// and the extra field are skipped, as well as zero fields that are omitted by
+// omitZero. Fields tagged with the "json" option are encoded as JSON documents.
+func structValues(rv reflect.Value, n *NamingStrategy) ([]string, []any, error) {
+	var (
+		columns []string
+		values  []any
+	)
+	for _, f := range valueFields(rv.Type(), n) {
+		fv, ok := valueByIndex(rv, f.index)
+		if !ok || omitZero(f) && fv.IsZero() {
+			continue
+		}
+		v, err := fieldValue(f, fv)
+		if err != nil {
+			return nil, nil, err
+		}
+		columns = append(columns, f.name)
+		values = append(values, v)
+	}
+	return columns, values, nil
+}
+
+// fieldValue returns the argument value of the given struct field.
+func fieldValue(f structField, fv reflect.Value) (any, error) {
+	if f.opts.Has("json") {
+		if nillable(fv.Type()) && fv.IsNil() {
+			return nil, nil
+		}
+		buf, err := json.Marshal(fv.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("sql: marshal json field %q: %w", f.name, err)
+		}
+		return string(buf), nil
+	}
+	if arrayType(fv.Type()) {
+		return arrayArg{v: fv}, nil
+	}
+	return fv.Interface(), nil
+}
+
+// valueByIndex returns the nested field of v corresponding to index,
+// and reports false if the path to the field goes through a nil pointer.
+func valueByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldByIndex returns the nested field of v corresponding to index.
+// Unlike reflect.Value.FieldByIndex, it allocates nil embedded pointers.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
 }
 
 func columnName(f reflect.StructField) string {
-	name := strings.ToLower(f.Name)
-	if tag, ok := f.Tag.Lookup("sql"); ok {
-		name = tag
-	} else if tag, ok := f.Tag.Lookup("json"); ok {
-		name = strings.Split(tag, ",")[0]
+	return DefaultNaming().ColumnName(f)
+}
+
+// tagOptions is the string following a comma in a struct field's tag.
+// For example, "prefix=g_,omitempty".
+type tagOptions string
+
+// Has reports whether the options contain the given flag.
+func (o tagOptions) Has(name string) bool {
+	for _, opt := range strings.Split(string(o), ",") {
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns the value of the given "key=value" option.
+func (o tagOptions) Value(key string) (string, bool) {
+	for _, opt := range strings.Split(string(o), ",") {
+		if k, v, ok := strings.Cut(opt, "="); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// scannable reports if the given struct type is scanned from a single
+// column (e.g. time.Time or sql.NullString), rather than being nested.
+func scannable(typ reflect.Type) bool {
+	return typ == timeType || assignable(typ) || reflect.PtrTo(typ).Implements(scannerType)
+}
+
+// indirect returns the element type of pointer types.
+func indirect(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Ptr {
+		return typ.Elem()
 	}
-	return name
+	return typ
 }
 
 func nillable(t reflect.Type) bool {
@@ -226,3 +1068,13 @@ func nillable(t reflect.Type) bool {
 	}
 	return false
 }
+
+// structArgs returns the columns and the values of the given struct
+// (or pointer to a struct) using the global naming strategy.
+func structArgs(v any) ([]string, []any, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("sql: invalid type %T. expect a struct or a pointer to a struct", v)
+	}
+	return structValues(rv, DefaultNaming())
+}
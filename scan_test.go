@@ -2,10 +2,13 @@ package duo
 
 import (
 	"database/sql"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestScanSlice(t *testing.T) {
@@ -44,6 +47,291 @@ func TestScanSlice(t *testing.T) {
 
 }
 
+func TestScanSlice_Nested(t *testing.T) {
+	type (
+		Base struct {
+			ID int
+		}
+		Model struct {
+			Base
+			Name string
+		}
+		Group struct {
+			ID   int
+			Name string
+			Rank Null[int]
+			Tags []string
+		}
+		User struct {
+			Model
+			Group *Group `sql:"group,prefix=g_"`
+			Owner Group
+		}
+	)
+	mock := sqlmock.NewRows([]string{"id", "name", "g_id", "g_name", "g_rank", "g_tags", "owner.id", "owner.name"}).
+		AddRow(1, "foo", 10, "admins", 1, "{a,b}", 2, "bar").
+		AddRow(2, "baz", nil, nil, nil, nil, 1, "foo")
+	users, err := ScanSlice[User](toRows(mock))
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, 1, users[0].ID)
+	assert.Equal(t, "foo", users[0].Name)
+	assert.Equal(t, &Group{ID: 10, Name: "admins", Rank: NullOf(1), Tags: []string{"a", "b"}}, users[0].Group)
+	assert.Equal(t, Group{ID: 2, Name: "bar"}, users[0].Owner)
+	assert.Equal(t, "baz", users[1].Name)
+	assert.Nil(t, users[1].Group, "all columns are NULL")
+	assert.Equal(t, Group{ID: 1, Name: "foo"}, users[1].Owner)
+
+	type Shadow struct {
+		Model
+		ID string
+	}
+	mock = sqlmock.NewRows([]string{"id", "name"}).AddRow("uuid", "foo")
+	shadows, err := ScanSlice[Shadow](toRows(mock))
+	assert.NoError(t, err)
+	assert.Equal(t, []Shadow{{Model: Model{Name: "foo"}, ID: "uuid"}}, shadows)
+}
+
+func TestScan_UnknownColumns(t *testing.T) {
+	type User struct {
+		ID   int
+		Name string
+	}
+	newRows := func() *sql.Rows {
+		return toRows(sqlmock.NewRows([]string{"id", "name", "age"}).AddRow(1, "foo", 30))
+	}
+	_, err := ScanSlice[User](newRows())
+	assert.EqualError(t, err, "sql/scan: missing struct field for column: age (age)")
+
+	users, err := ScanSlice[User](newRows(), IgnoreUnknown())
+	assert.NoError(t, err)
+	assert.Equal(t, []User{{1, "foo"}}, users)
+
+	_, err = ScanSlice[User](newRows(), CollectUnknown())
+	assert.Error(t, err)
+
+	type ExtraUser struct {
+		ID    int
+		Name  string
+		Extra map[string]any `sql:",extra"`
+	}
+	u, err := Scan[ExtraUser](newRows(), CollectUnknown())
+	assert.NoError(t, err)
+	assert.Equal(t, ExtraUser{ID: 1, Name: "foo", Extra: map[string]any{"age": int64(30)}}, u)
+
+	var one User
+	assert.NoError(t, ScanOne(newRows(), &one, IgnoreUnknown()))
+	assert.Equal(t, User{1, "foo"}, one)
+
+	names, err := ScanSlice[string](toRows(sqlmock.NewRows([]string{"name", "age"}).AddRow("foo", 1)), IgnoreUnknown())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, names)
+}
+
+func TestScanSlice_FieldTypes(t *testing.T) {
+	type (
+		Role string
+		Base struct {
+			ID      int8
+			Created time.Time
+		}
+		User struct {
+			*Base
+			Name   Role
+			Active bool
+			Score  float64
+			Nick   *string
+			Tags   []byte
+			Null   sql.NullString
+		}
+	)
+	now := time.Now()
+	mock := sqlmock.NewRows([]string{"id", "created", "name", "active", "score", "nick", "tags", "null"}).
+		AddRow(1, now, "admin", true, 1.5, "a8m", []byte("a,b"), "foo").
+		AddRow(nil, nil, nil, nil, nil, nil, nil, nil)
+	users, err := ScanSlice[User](toRows(mock))
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	nick := "a8m"
+	assert.Equal(t, User{
+		Base:   &Base{ID: 1, Created: now},
+		Name:   "admin",
+		Active: true,
+		Score:  1.5,
+		Nick:   &nick,
+		Tags:   []byte("a,b"),
+		Null:   sql.NullString{String: "foo", Valid: true},
+	}, users[0])
+	assert.Equal(t, User{}, users[1])
+
+	mock = sqlmock.NewRows([]string{"id"}).AddRow(1000)
+	_, err = ScanSlice[Base](toRows(mock))
+	assert.EqualError(t, err, `sql/scan: column "id" (row 0) into Base.ID: value 1000 overflows int8 (value: 1000)`)
+}
+
+// testAttrs is a map Scanner that fills the existing map.
+type testAttrs map[string]any
+
+func (a *testAttrs) Scan(src any) error {
+	if src == nil {
+		return nil
+	}
+	b, ok := src.([]byte)
+	if !ok {
+		b = []byte(src.(string))
+	}
+	return json.Unmarshal(b, a)
+}
+
+func TestScanSlice_ScannerHolders(t *testing.T) {
+	type T struct {
+		Attrs testAttrs
+	}
+	mock := sqlmock.NewRows([]string{"attrs"}).
+		AddRow(`{"a":1}`).
+		AddRow(`{"b":2}`).
+		AddRow(nil)
+	ts, err := ScanSlice[T](toRows(mock))
+	require.NoError(t, err)
+	require.Len(t, ts, 3)
+	assert.Equal(t, testAttrs{"a": 1.0}, ts[0].Attrs)
+	assert.Equal(t, testAttrs{"b": 2.0}, ts[1].Attrs)
+	assert.Nil(t, ts[2].Attrs)
+
+	mock = sqlmock.NewRows([]string{"attrs"}).
+		AddRow(`{"a":1}`).
+		AddRow(nil).
+		AddRow(`{"b":2}`)
+	attrs, err := ScanSlice[testAttrs](toRows(mock))
+	require.NoError(t, err)
+	assert.Equal(t, []testAttrs{{"a": 1.0}, nil, {"b": 2.0}}, attrs)
+}
+
+func TestScanSlice_JSON(t *testing.T) {
+	type (
+		Address struct {
+			City string `json:"city"`
+		}
+		User struct {
+			ID      int
+			Attrs   map[string]any `sql:"attrs,json"`
+			Tags    []string       `sql:"tags,json"`
+			Address *Address       `sql:"address,json"`
+		}
+	)
+	mock := sqlmock.NewRows([]string{"id", "attrs", "tags", "address"}).
+		AddRow(1, `{"a":1}`, []byte(`["a","b"]`), `{"city":"TLV"}`).
+		AddRow(2, nil, nil, nil)
+	users, err := ScanSlice[User](toRows(mock))
+	require.NoError(t, err)
+	assert.Equal(t, []User{
+		{ID: 1, Attrs: map[string]any{"a": 1.0}, Tags: []string{"a", "b"}, Address: &Address{City: "TLV"}},
+		{ID: 2},
+	}, users)
+
+	mock = sqlmock.NewRows([]string{"id", "tags"}).AddRow(1, "{")
+	_, err = ScanSlice[User](toRows(mock))
+	require.ErrorContains(t, err, `sql/scan: column "tags" (row 0) into User.Tags: unmarshal json`)
+
+	query, args := Dialect(Postgres).Insert("users").SetStruct(users[0]).Query()
+	assert.Equal(t, `INSERT INTO "users" ("id", "attrs", "tags", "address") VALUES ($1, $2, $3, $4)`, query)
+	assert.Equal(t, []any{1, `{"a":1}`, `["a","b"]`, `{"city":"TLV"}`}, args)
+
+	query, args = Dialect(MySQL).Update("users").SetStruct(&users[1]).Query()
+	assert.Equal(t, "UPDATE `users` SET `id` = ?, `attrs` = ?, `tags` = ?, `address` = ?", query)
+	assert.Equal(t, []any{2, nil, nil, nil}, args)
+}
+
+func TestSetStruct_OmitZero(t *testing.T) {
+	type User struct {
+		ID   int    `sql:"id,auto"`
+		Name string `sql:"name,omitempty"`
+		Nick string `json:"nick,omitempty"`
+	}
+	query, args := Dialect(Postgres).Insert("users").SetStruct(&User{}).Query()
+	assert.Equal(t, `INSERT INTO "users" ("nick") VALUES ($1)`, query)
+	assert.Equal(t, []any{""}, args)
+
+	query, args = Dialect(Postgres).Update("users").SetStruct(&User{ID: 1}).Query()
+	assert.Equal(t, `UPDATE "users" SET "id" = $1, "nick" = $2`, query)
+	assert.Equal(t, []any{1, ""}, args)
+}
+
+func TestScanSets(t *testing.T) {
+	type User struct {
+		ID   int
+		Name string
+	}
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectQuery("CALL users_page").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(2, "bar"),
+		sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(10),
+	)
+	rows, err := db.Query("CALL users_page(?, ?)", 0, 2)
+	require.NoError(t, err)
+	var (
+		users []User
+		total int
+	)
+	require.NoError(t, ScanSets(rows, &users, &total))
+	assert.Equal(t, []User{{1, "foo"}, {2, "bar"}}, users)
+	assert.Equal(t, 10, total)
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo"))
+	rows, err = db.Query("SELECT id, name FROM users")
+	require.NoError(t, err)
+	var user User
+	assert.EqualError(t, ScanSets(rows, &user, &total), "sql/scan: expect 2 result sets, got 1")
+	assert.Equal(t, User{1, "foo"}, user)
+
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "age"}).AddRow(1, "foo", 30),
+		sqlmock.NewRows([]string{"id", "name", "age"}).AddRow(2, "bar", 40),
+	)
+	rows, err = db.Query("SELECT * FROM users")
+	require.NoError(t, err)
+	var admins []User
+	err = ScanSets(rows, &user, &admins)
+	require.ErrorContains(t, err, "missing struct field for column: age")
+
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "age"}).AddRow(1, "foo", 30),
+		sqlmock.NewRows([]string{"id", "name", "age"}).AddRow(2, "bar", 40),
+	)
+	rows, err = db.Query("SELECT * FROM users")
+	require.NoError(t, err)
+	require.NoError(t, ScanSets(rows, &user, IgnoreUnknown(), &admins))
+	assert.Equal(t, User{1, "foo"}, user)
+	assert.Equal(t, []User{{2, "bar"}}, admins)
+}
+
+func BenchmarkScanSlice(b *testing.B) {
+	type User struct {
+		ID      int64
+		Name    string
+		Score   float64
+		Active  bool
+		Created time.Time
+		Nick    *string
+	}
+	now := time.Now()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		mock := sqlmock.NewRows([]string{"id", "name", "score", "active", "created", "nick"})
+		for j := 0; j < 1000; j++ {
+			mock.AddRow(int64(j), "a8m", 1.5, true, now, nil)
+		}
+		rows := toRows(mock)
+		b.StartTimer()
+		if _, err := ScanSlice[User](rows); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func toRows(mrows *sqlmock.Rows) *sql.Rows {
 	db, mock, _ := sqlmock.New()
 	mock.ExpectQuery("").WillReturnRows(mrows)
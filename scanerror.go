@@ -0,0 +1,256 @@
+package duo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ScanError describes a failure to scan a column into its Go destination.
+// It is returned (wrapped) by the scanning functions for errors that occur
+// while scanning a row, and for columns that are detected as incompatible
+// with their destination before the first row is read.
+type ScanError struct {
+	// Row is the index of the failed row in the result set, or -1
+	// if the error was detected before the first row was read.
+	Row int
+	// Column is the name of the column in the result set.
+	Column string
+	// DatabaseType is the type name of the column as reported by the driver.
+	DatabaseType string
+	// Field is the path of the destination Go field. For example, User.Address.City.
+	Field string
+	// Value is the value of the column (if known). Long values are truncated.
+	Value any
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ScanError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "sql/scan: column %q", e.Column)
+	var info []string
+	if e.Row >= 0 {
+		info = append(info, fmt.Sprintf("row %d", e.Row))
+	}
+	if e.DatabaseType != "" {
+		info = append(info, e.DatabaseType)
+	}
+	if len(info) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(info, ", "))
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&b, " into %s", e.Field)
+	}
+	fmt.Fprintf(&b, ": %s", strings.TrimPrefix(e.Err.Error(), "sql/scan: "))
+	if e.Value != nil {
+		fmt.Fprintf(&b, " (value: %v)", e.Value)
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+// columnField describes the Go destination of a column.
+type columnField struct {
+	// column name and destination field path.
+	column, path string
+	// typ of the destination. Nil if the column is decoded by a
+	// converter or a Scanner, and its compatibility is not checked.
+	typ reflect.Type
+}
+
+// fieldPath returns the path of the field at the given index of the struct.
+func fieldPath(typ reflect.Type, index []int) string {
+	path := []string{typeName(typ)}
+	for _, i := range index {
+		typ = indirect(typ)
+		f := typ.Field(i)
+		path = append(path, f.Name)
+		typ = f.Type
+	}
+	return strings.Join(path, ".")
+}
+
+// typeName returns the name of the given type, without its package.
+func typeName(typ reflect.Type) string {
+	if typ.Name() != "" {
+		return typ.Name()
+	}
+	return typ.String()
+}
+
+// maxValueLen is the maximum length of values that are reported in errors.
+const maxValueLen = 64
+
+// truncate truncates long textual values for reporting.
+func truncate(v any) any {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return v
+	}
+	if len(s) > maxValueLen {
+		s = s[:maxValueLen] + "..."
+	}
+	return s
+}
+
+// fieldError returns the ScanError of a failure to assign the given scanned value.
+func fieldError(f columnField, v any, err error) error {
+	var se *ScanError
+	if errors.As(err, &se) {
+		return err
+	}
+	se = &ScanError{Column: f.column, Field: f.path, Err: err}
+	if v, ok := scannedValue(v); ok {
+		se.Value = truncate(v)
+	}
+	return se
+}
+
+// scanFailed returns the error of a failure to scan (or assign) the row at the given
+// index. Errors of the rows.Scan method are converted to ScanError if the failed
+// column can be detected using the destinations of the failed call, and the database
+// type of the column is added to the error.
+func scanFailed(rows ColumnScanner, scan *rowScan, row int, err error, dest ...any) error {
+	columns, cerr := rows.Columns()
+	if cerr != nil {
+		return fmt.Errorf("sql/scan: failed scanning rows: %w", err)
+	}
+	var (
+		i  = -1
+		se *ScanError
+	)
+	if errors.As(err, &se) {
+		for j := range columns {
+			if columns[j] == se.Column {
+				i = j
+				break
+			}
+		}
+	} else {
+		if i = failedColumn(rows, dest); i < 0 || i >= len(columns) {
+			return fmt.Errorf("sql/scan: failed scanning rows: %w", err)
+		}
+		se = &ScanError{Column: columns[i], Err: err}
+		if scan != nil && i < len(scan.fields) {
+			se.Field = scan.fields[i].path
+		}
+		// The current row can be scanned again. Read
+		// its raw values to report the offending one.
+		raw := make([]any, len(columns))
+		for j := range raw {
+			raw[j] = new(any)
+		}
+		if rows.Scan(raw...) == nil {
+			se.Value = truncate(*raw[i].(*any))
+		}
+	}
+	se.Row = row
+	if types, terr := rows.ColumnTypes(); terr == nil && i >= 0 && i < len(types) {
+		se.DatabaseType = types[i].DatabaseTypeName()
+	}
+	return se
+}
+
+// failedColumn returns the index of the destination that fails to be scanned, or
+// -1 if it is not detected. The current row is scanned again with one destination
+// at a time, and the other columns are scanned into interface values.
+func failedColumn(rows ColumnScanner, dest []any) int {
+	probe := make([]any, len(dest))
+	for j := range probe {
+		probe[j] = new(any)
+	}
+	for j := range dest {
+		probe[j] = dest[j]
+		if rows.Scan(probe...) != nil {
+			return j
+		}
+		probe[j] = new(any)
+	}
+	return -1
+}
+
+// validateColumns checks the compatibility of the column types reported by the driver
+// with their destinations, to fail before the first row is read. Only conversions that
+// always fail are reported, such as scanning a time column into an integer field.
+func validateColumns(rows ColumnScanner, scan *rowScan) error {
+	checked := false
+	for _, f := range scan.fields {
+		if f.typ != nil {
+			checked = true
+			break
+		}
+	}
+	if !checked {
+		return nil
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	for i, f := range scan.fields {
+		if i >= len(types) || f.typ == nil {
+			continue
+		}
+		st := types[i].ScanType()
+		if st == nil || compatible(columnKind(st), indirect(f.typ)) {
+			continue
+		}
+		return &ScanError{
+			Row:          -1,
+			Column:       f.column,
+			DatabaseType: types[i].DatabaseTypeName(),
+			Field:        f.path,
+			Err:          fmt.Errorf("incompatible column type %s for %s", st, f.typ),
+		}
+	}
+	return nil
+}
+
+// kinds of column values, as reported by the driver.
+const (
+	columnUnknown = iota
+	columnNumeric
+	columnTime
+)
+
+// columnKind returns the kind of values of the given column scan type.
+func columnKind(st reflect.Type) int {
+	switch st {
+	case timeType, reflect.TypeOf(sql.NullTime{}):
+		return columnTime
+	case reflect.TypeOf(sql.NullInt64{}), reflect.TypeOf(sql.NullInt32{}), reflect.TypeOf(sql.NullInt16{}),
+		reflect.TypeOf(sql.NullByte{}), reflect.TypeOf(sql.NullFloat64{}), reflect.TypeOf(sql.NullBool{}):
+		return columnNumeric
+	}
+	switch k := st.Kind(); {
+	case k == reflect.Bool, k >= reflect.Int && k <= reflect.Float64:
+		return columnNumeric
+	}
+	return columnUnknown
+}
+
+// compatible reports if a column of the given kind can be scanned into the given
+// type. Textual and binary destinations accept any column, as they are formatted.
+func compatible(kind int, typ reflect.Type) bool {
+	k := typ.Kind()
+	switch {
+	case kind == columnTime:
+		return k != reflect.Bool && (k < reflect.Int || k > reflect.Float64)
+	case kind == columnNumeric:
+		return typ != timeType
+	}
+	return true
+}
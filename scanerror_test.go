@@ -0,0 +1,78 @@
+package duo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanError(t *testing.T) {
+	type (
+		Address struct {
+			Zip int
+		}
+		User struct {
+			ID      int
+			Age     int
+			Address Address
+		}
+	)
+	mock := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("INT", ""),
+		sqlmock.NewColumn("age").OfType("INT", ""),
+		sqlmock.NewColumn("address.zip").OfType("VARCHAR", ""),
+	).AddRow(1, 30, 1000).AddRow(2, 40, strings.Repeat("x", 100))
+	_, err := ScanSlice[User](toRows(mock))
+	var se *ScanError
+	require.True(t, errors.As(err, &se))
+	assert.Equal(t, 1, se.Row)
+	assert.Equal(t, "address.zip", se.Column)
+	assert.Equal(t, "VARCHAR", se.DatabaseType)
+	assert.Equal(t, "User.Address.Zip", se.Field)
+	assert.Equal(t, strings.Repeat("x", 64)+"...", se.Value)
+	assert.Contains(t, err.Error(), `sql/scan: column "address.zip" (row 1, VARCHAR) into User.Address.Zip: sql: Scan error on column index 2`)
+
+	mock = sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("INT", int64(0)),
+		sqlmock.NewColumn("age").OfType("DATETIME", time.Time{}),
+	).AddRow(1, time.Now())
+	rows := toRows(mock)
+	_, err = ScanSlice[User](rows, IgnoreUnknown())
+	assert.EqualError(t, err, `sql/scan: column "age" (DATETIME) into User.Age: incompatible column type time.Time for int`)
+	require.True(t, errors.As(err, &se))
+	assert.Equal(t, -1, se.Row)
+	require.NoError(t, rows.Close())
+}
+
+// opaqueRows hides the error messages of the database/sql package.
+type opaqueRows struct {
+	ColumnScanner
+}
+
+func (r opaqueRows) Scan(dest ...any) error {
+	if err := r.ColumnScanner.Scan(dest...); err != nil {
+		return errors.New("scan failed")
+	}
+	return nil
+}
+
+func TestScanError_Column(t *testing.T) {
+	type User struct {
+		ID   int
+		Name string
+		Age  int
+	}
+	mock := sqlmock.NewRows([]string{"id", "name", "age"}).AddRow(1, "a8m", "x")
+	_, err := ScanSlice[User](opaqueRows{toRows(mock)})
+	var se *ScanError
+	require.True(t, errors.As(err, &se))
+	assert.Equal(t, "age", se.Column)
+	assert.Equal(t, "User.Age", se.Field)
+	assert.Equal(t, "x", se.Value)
+	assert.EqualError(t, err, `sql/scan: column "age" (row 0) into User.Age: scan failed (value: x)`)
+}
@@ -0,0 +1,161 @@
+package duo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrDuplicateKey is returned by ScanMap when two rows have the same key.
+var ErrDuplicateKey = errors.New("sql/scan: duplicate key")
+
+// ScanMap scans all rows into values of type V (a struct or a pointer to a struct),
+// and returns them in a map keyed by the given column. The key can be either a
+// column name or the name of a Go field of V, and its value must be convertible
+// to K. Rows with duplicate keys fail the scan with ErrDuplicateKey. For example:
+//
+//	// SELECT * FROM users WHERE id IN (...)
+//	users, err := duo.ScanMap[int, User](rows, "id")
+func ScanMap[K comparable, V any](rows ColumnScanner, key string, opts ...ScanOption) (map[K]V, error) {
+	res := make(map[K]V)
+	err := scanKeyed(rows, key, opts, func(k K, v V) error {
+		if _, ok := res[k]; ok {
+			return fmt.Errorf("%w %v for column %q", ErrDuplicateKey, k, key)
+		}
+		res[k] = v
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ScanGroup scans all rows into values of type V, and groups them by the given key
+// column (or Go field), as in ScanMap. Values of each group are kept in the order of
+// the result set. For example:
+//
+//	// SELECT * FROM posts WHERE user_id IN (...)
+//	posts, err := duo.ScanGroup[int, Post](rows, "user_id")
+func ScanGroup[K comparable, V any](rows ColumnScanner, key string, opts ...ScanOption) (map[K][]V, error) {
+	res := make(map[K][]V)
+	err := scanKeyed(rows, key, opts, func(k K, v V) error {
+		res[k] = append(res[k], v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// scanKeyed scans the rows into values of type V, and calls fn
+// with each value and its key that is read from the given field.
+func scanKeyed[K comparable, V any](rows ColumnScanner, key string, opts []ScanOption, fn func(K, V) error) error {
+	cfg := newScanConfig(opts)
+	scan, err := scanRows[V](rows, cfg)
+	if err != nil {
+		return err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	var (
+		vtyp = reflect.TypeOf((*V)(nil)).Elem()
+		ktyp = reflect.TypeOf((*K)(nil)).Elem()
+	)
+	f, kc, err := keyField(vtyp, key, columns, cfg.naming)
+	if err != nil {
+		return err
+	}
+	if !keyConvertible(f.typ, ktyp) {
+		return fmt.Errorf("sql/scan: key field %s of type %s is not convertible to %s", key, f.typ, ktyp)
+	}
+	values := scan.values()
+	for i := 0; rows.Next(); i++ {
+		if err := rows.Scan(values...); err != nil {
+			return scanFailed(rows, scan, i, err, values...)
+		}
+		if _, ok := scannedValue(values[kc]); !ok {
+			return scanFailed(rows, scan, i, fieldError(scan.fields[kc], nil, errors.New("NULL key value")))
+		}
+		var (
+			k K
+			v V
+		)
+		rv := reflect.ValueOf(&v).Elem()
+		if err := scan.assign(rv, values...); err != nil {
+			return scanFailed(rows, scan, i, err)
+		}
+		if fv, ok := valueByIndex(reflect.Indirect(rv), f.index); ok {
+			kv := fv.Convert(ktyp)
+			// Integer conversions are checked for overflow.
+			if kv.Convert(fv.Type()).Interface() != fv.Interface() {
+				return scanFailed(rows, scan, i, fieldError(scan.fields[kc], values[kc], fmt.Errorf("key value %v overflows %s", fv, ktyp)))
+			}
+			reflect.ValueOf(&k).Elem().Set(kv)
+		}
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// keyConvertible reports if values of the key field type can be converted to the map
+// key type without changing their meaning: assignable types, types of the same kind
+// (e.g. a named string type to string) and integer types. Conversions between numbers
+// and strings (e.g. 65 to "A") and between floats and integers are rejected.
+func keyConvertible(from, to reflect.Type) bool {
+	integer := func(k reflect.Kind) bool {
+		return k >= reflect.Int && k <= reflect.Uintptr
+	}
+	switch fk, tk := from.Kind(), to.Kind(); {
+	case from.AssignableTo(to):
+		return true
+	case !from.ConvertibleTo(to) || !from.Comparable():
+		return false
+	default:
+		return fk == tk || integer(fk) && integer(tk)
+	}
+}
+
+// keyField returns the field of the given struct type that is mapped to the key,
+// and the index of its column. The key is matched against the column names of the
+// fields, and then against their Go names. The column of the field is expected to
+// be in the result set.
+func keyField(typ reflect.Type, key string, columns []string, n *NamingStrategy) (structField, int, error) {
+	st := indirect(typ)
+	if st.Kind() != reflect.Struct || scannable(st) {
+		return structField{}, -1, fmt.Errorf("sql/scan: invalid value type %s. expect a struct or a pointer to a struct", typ)
+	}
+	var (
+		field  structField
+		found  bool
+		fields = structFields(st, n)
+	)
+	for _, f := range fields {
+		if n.normalize(f.name) == n.normalize(key) {
+			field, found = f, true
+			break
+		}
+	}
+	if sf, ok := st.FieldByName(key); ok && !found {
+		for _, f := range fields {
+			if reflect.DeepEqual(f.index, sf.Index) {
+				field, found = f, true
+				break
+			}
+		}
+	}
+	if !found {
+		return structField{}, -1, fmt.Errorf("sql/scan: missing key field %q in %s", key, st)
+	}
+	for i, c := range columns {
+		if n.normalize(c) == n.normalize(field.name) {
+			return field, i, nil
+		}
+	}
+	return structField{}, -1, fmt.Errorf("sql/scan: missing key column %q in result set", field.name)
+}
@@ -0,0 +1,69 @@
+package duo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanMap(t *testing.T) {
+	type User struct {
+		ID   int64
+		Name string
+	}
+	rows := toRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(2, "bar"))
+	users, err := ScanMap[int, User](rows, "id")
+	require.NoError(t, err)
+	assert.Equal(t, map[int]User{1: {1, "foo"}, 2: {2, "bar"}}, users)
+
+	rows = toRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(2, "bar"))
+	byName, err := ScanMap[string, *User](rows, "Name")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*User{"foo": {1, "foo"}, "bar": {2, "bar"}}, byName)
+
+	rows = toRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(1, "bar"))
+	_, err = ScanMap[int, User](rows, "id")
+	assert.True(t, errors.Is(err, ErrDuplicateKey))
+	assert.EqualError(t, err, `sql/scan: duplicate key 1 for column "id"`)
+
+	rows = toRows(sqlmock.NewRows([]string{"name"}).AddRow("foo"))
+	_, err = ScanMap[int, User](rows, "id")
+	assert.EqualError(t, err, `sql/scan: missing key column "id" in result set`)
+
+	rows = toRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	_, err = ScanMap[int, User](rows, "age")
+	assert.EqualError(t, err, `sql/scan: missing key field "age" in duo.User`)
+
+	rows = toRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(65, "foo"))
+	_, err = ScanMap[string, User](rows, "id")
+	assert.EqualError(t, err, `sql/scan: key field id of type int64 is not convertible to string`)
+
+	rows = toRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(300, "foo"))
+	_, err = ScanMap[int8, User](rows, "id")
+	assert.EqualError(t, err, `sql/scan: column "id" (row 0) into User.ID: key value 300 overflows int8 (value: 300)`)
+
+	rows = toRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(nil, "bar"))
+	_, err = ScanMap[int, User](rows, "id")
+	assert.EqualError(t, err, `sql/scan: column "id" (row 1) into User.ID: NULL key value`)
+
+	type Item struct {
+		Price float64
+	}
+	rows = toRows(sqlmock.NewRows([]string{"price"}).AddRow(1.5))
+	_, err = ScanMap[int, Item](rows, "price")
+	assert.EqualError(t, err, `sql/scan: key field price of type float64 is not convertible to int`)
+}
+
+func TestScanGroup(t *testing.T) {
+	type Post struct {
+		ID     int
+		UserID int `sql:"user_id"`
+	}
+	rows := toRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(1, 10).AddRow(2, 20).AddRow(3, 10))
+	posts, err := ScanGroup[int, Post](rows, "user_id")
+	require.NoError(t, err)
+	assert.Equal(t, map[int][]Post{10: {{1, 10}, {3, 10}}, 20: {{2, 20}}}, posts)
+}
@@ -0,0 +1,324 @@
+package duo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+type (
+	// structConfig holds the configuration of the struct builders.
+	structConfig struct {
+		upsert bool
+	}
+
+	// StructOption allows configuring the struct
+	// builders using functional options.
+	StructOption func(*structConfig)
+)
+
+// WithUpsert configures InsertStruct and InsertStructs to update the existing
+// rows on conflict, using the primary key of the struct as the conflict target.
+// The inserted columns, except for the primary key, are set to their new values,
+// and if there are no such columns, the conflicting rows are left as-is.
+func WithUpsert() StructOption {
+	return func(c *structConfig) {
+		c.upsert = true
+	}
+}
+
+// InsertStruct creates a builder for the `INSERT INTO` statement of one row from
+// the fields of a struct (or a pointer to a struct). Columns are derived using the
+// same tag rules as the scanning functions, and fields tagged with the "omitempty"
+// or the "auto" (auto-increment) options are skipped if they hold the zero value.
+//
+//	type User struct {
+//		ID   int    `sql:"id,pk,auto"`
+//		Name string `sql:"name"`
+//		Nick string `sql:"nick,omitempty"`
+//	}
+//
+//	InsertStruct("users", &User{Name: "a8m"}, WithUpsert())
+func InsertStruct(table string, v any, opts ...StructOption) *InsertBuilder {
+	return Insert(table).structs([]reflect.Value{reflect.ValueOf(v)}, opts, DefaultNaming())
+}
+
+// InsertStructs creates a builder for the `INSERT INTO` statement of multiple rows
+// from the given structs, as in InsertStruct. The columns are picked for the whole
+// batch: a column tagged with the "omitempty" option is skipped only if it is zero
+// in all rows, and a column tagged with the "auto" option is skipped unless it is
+// set in every row, as the database cannot generate it for some of the rows.
+func InsertStructs[T any](table string, vs []T, opts ...StructOption) *InsertBuilder {
+	rows := make([]reflect.Value, len(vs))
+	for i := range vs {
+		rows[i] = reflect.ValueOf(vs[i])
+	}
+	return Insert(table).structs(rows, opts, DefaultNaming())
+}
+
+// InsertStruct creates an InsertBuilder for the configured dialect.
+// See the package-level InsertStruct function for details.
+func (d *DialectBuilder) InsertStruct(table string, v any, opts ...StructOption) *InsertBuilder {
+	b := d.Insert(table)
+	return b.structs([]reflect.Value{reflect.ValueOf(v)}, opts, DefaultNaming())
+}
+
+// InsertStructs creates an InsertBuilder of multiple rows for the configured
+// dialect. vs must be a slice of structs (or pointers to structs). See the
+// package-level InsertStructs function for details.
+func (d *DialectBuilder) InsertStructs(table string, vs any, opts ...StructOption) *InsertBuilder {
+	b := d.Insert(table)
+	rv := reflect.ValueOf(vs)
+	if rv.Kind() != reflect.Slice {
+		b.AddError(fmt.Errorf("sql: invalid type %T. expect a slice of structs", vs))
+		return b
+	}
+	rows := make([]reflect.Value, rv.Len())
+	for i := range rows {
+		rows[i] = rv.Index(i)
+	}
+	return b.structs(rows, opts, DefaultNaming())
+}
+
+// structs sets the columns and the values of the given struct rows,
+// using the given naming strategy.
+func (i *InsertBuilder) structs(rows []reflect.Value, opts []StructOption, n *NamingStrategy) *InsertBuilder {
+	var cfg structConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if len(rows) == 0 {
+		i.AddError(errors.New("sql: no structs to insert"))
+		return i
+	}
+	for j := range rows {
+		if rows[j].Kind() == reflect.Interface {
+			rows[j] = rows[j].Elem()
+		}
+		if !rows[j].IsValid() || rows[j].Kind() == reflect.Ptr && rows[j].IsNil() {
+			i.AddError(fmt.Errorf("sql: nil struct at index %d", j))
+			return i
+		}
+		if rows[j] = reflect.Indirect(rows[j]); rows[j].Kind() != reflect.Struct {
+			i.AddError(fmt.Errorf("sql: invalid type %s. expect a struct or a pointer to a struct", rows[j].Type()))
+			return i
+		}
+		if t := rows[j].Type(); t != rows[0].Type() {
+			i.AddError(fmt.Errorf("sql: mismatched struct types %s and %s", rows[0].Type(), t))
+			return i
+		}
+	}
+	var (
+		all    = valueFields(rows[0].Type(), n)
+		fields []structField
+	)
+	for _, f := range all {
+		if omitColumn(rows, f) {
+			continue
+		}
+		fields = append(fields, f)
+		i.Columns(f.name)
+	}
+	for _, rv := range rows {
+		values := make([]any, len(fields))
+		for j, f := range fields {
+			fv, ok := valueByIndex(rv, f.index)
+			if !ok {
+				continue
+			}
+			v, err := fieldValue(f, fv)
+			if err != nil {
+				i.AddError(err)
+				return i
+			}
+			values[j] = v
+		}
+		i.Values(values...)
+	}
+	if cfg.upsert {
+		pk := primaryKey(all, n)
+		if len(pk) == 0 {
+			i.AddError(fmt.Errorf("sql: missing primary key field (`sql:\",pk\"`) in %s for upsert", rows[0].Type()))
+			return i
+		}
+		var (
+			target = make([]string, len(pk))
+			keys   = make(map[string]bool, len(pk))
+			update []string
+		)
+		for j := range pk {
+			target[j], keys[pk[j].name] = pk[j].name, true
+		}
+		for _, f := range fields {
+			if !keys[f.name] {
+				update = append(update, f.name)
+			}
+		}
+		// Conflicting rows of key-only structs are kept as-is. ResolveWithIgnore is
+		// used rather than DoNothing, as MySQL does not support the latter.
+		if len(update) == 0 {
+			i.OnConflict(ConflictColumns(target...), ResolveWithIgnore())
+			return i
+		}
+		i.OnConflict(ConflictColumns(target...), ResolveWith(func(u *UpdateSet) {
+			for _, c := range update {
+				u.SetExcluded(c)
+			}
+		}))
+	}
+	return i
+}
+
+// omitColumn reports if the column of the given field is omitted from the inserted
+// rows. It extends the omitZero rule to batches: auto columns are omitted if they
+// are zero in any row, and omitempty columns are omitted if they are zero in all.
+func omitColumn(rows []reflect.Value, f structField) bool {
+	if !omitZero(f) {
+		return false
+	}
+	auto := f.opts.Has("auto")
+	for _, rv := range rows {
+		fv, ok := valueByIndex(rv, f.index)
+		if zero := !ok || fv.IsZero(); zero == auto {
+			return auto
+		}
+	}
+	return !auto
+}
+
+// UpdateStruct creates a builder for the `UPDATE` statement of the columns that differ
+// between the original and the modified structs (or pointers to structs) of the same
+// type. The primary key of the original struct is used as the `WHERE` predicate. If
+// no columns were changed, the returned builder is Empty and should not be executed.
+//
+//	u := UpdateStruct("users", orig, user)
+//	if !u.Empty() {
+//		query, args := u.Query()
+//		// ...
+//	}
+func UpdateStruct(table string, original, modified any) *UpdateBuilder {
+	return Update(table).diff(reflect.ValueOf(original), reflect.ValueOf(modified), DefaultNaming())
+}
+
+// UpdateStruct creates an UpdateBuilder for the configured dialect.
+// See the package-level UpdateStruct function for details.
+func (d *DialectBuilder) UpdateStruct(table string, original, modified any) *UpdateBuilder {
+	b := d.Update(table)
+	return b.diff(reflect.ValueOf(original), reflect.ValueOf(modified), DefaultNaming())
+}
+
+// Tracked tracks the changes of a struct entity since it was loaded (or last saved),
+// and builds the `UPDATE` statement of the changed columns. For example:
+//
+//	t := Track(&user)
+//	user.Name = "a8m"
+//	u := t.Update("users")
+//	if !u.Empty() {
+//		// Execute the statement and then mark the entity as saved.
+//		t.Reset()
+//	}
+//
+// Changes are detected by comparing the field values with a copy of the entity, and
+// therefore slices and maps should be replaced rather than modified in place.
+type Tracked[T any] struct {
+	v    *T
+	orig T
+}
+
+// Track starts tracking the changes of the struct pointed to by v.
+func Track[T any](v *T) *Tracked[T] {
+	return &Tracked[T]{v: v, orig: *v}
+}
+
+// Entity returns the tracked entity.
+func (t *Tracked[T]) Entity() *T {
+	return t.v
+}
+
+// Changed returns the names of the columns that were changed since the entity was tracked.
+// It fails if the update of the changes cannot be built, as in UpdateStruct (e.g. a field
+// failed to be encoded, or the struct does not have a primary key).
+func (t *Tracked[T]) Changed() ([]string, error) {
+	u := Update("").diff(reflect.ValueOf(&t.orig), reflect.ValueOf(t.v), DefaultNaming())
+	if err := u.Err(); err != nil {
+		return nil, err
+	}
+	return u.columns, nil
+}
+
+// Update creates a builder for the `UPDATE` statement of the changed columns,
+// as in UpdateStruct. Use UpdateBuilder.SetDialect to configure its dialect.
+func (t *Tracked[T]) Update(table string) *UpdateBuilder {
+	return UpdateStruct(table, &t.orig, t.v)
+}
+
+// Reset marks the current state of the entity as unchanged.
+func (t *Tracked[T]) Reset() {
+	t.orig = *t.v
+}
+
+// diff sets the columns that differ between the original and the modified struct values,
+// and adds the primary key of the original struct as the `WHERE` predicate. Columns are
+// derived using the given naming strategy.
+func (u *UpdateBuilder) diff(orig, mod reflect.Value, n *NamingStrategy) *UpdateBuilder {
+	for _, rv := range []*reflect.Value{&orig, &mod} {
+		if !rv.IsValid() || rv.Kind() == reflect.Ptr && rv.IsNil() {
+			u.AddError(errors.New("sql: nil struct in update"))
+			return u
+		}
+		if *rv = reflect.Indirect(*rv); rv.Kind() != reflect.Struct {
+			u.AddError(fmt.Errorf("sql: invalid type %s. expect a struct or a pointer to a struct", rv.Type()))
+			return u
+		}
+	}
+	if orig.Type() != mod.Type() {
+		u.AddError(fmt.Errorf("sql: mismatched struct types %s and %s", orig.Type(), mod.Type()))
+		return u
+	}
+	var (
+		fields = valueFields(orig.Type(), n)
+		pk     = primaryKey(fields, n)
+	)
+	if len(pk) == 0 {
+		u.AddError(fmt.Errorf("sql: missing primary key field (`sql:\",pk\"`) in %s for update", orig.Type()))
+		return u
+	}
+	for _, f := range pk {
+		fv, ok := valueByIndex(orig, f.index)
+		if !ok {
+			u.AddError(fmt.Errorf("sql: missing primary key value for column %q", f.name))
+			return u
+		}
+		u.Where(EQ(f.name, fv.Interface()))
+	}
+	for _, f := range fields {
+		ov, ook := valueByIndex(orig, f.index)
+		mv, mok := valueByIndex(mod, f.index)
+		if !mok || ook && equalField(ov, mv) {
+			continue
+		}
+		v, err := fieldValue(f, mv)
+		if err != nil {
+			u.AddError(err)
+			return u
+		}
+		u.Set(f.name, v)
+	}
+	return u
+}
+
+// equalField reports if the two values of a struct field are equal. Time values
+// (or pointers to them) are equal if they represent the same instant.
+func equalField(a, b reflect.Value) bool {
+	for a.Kind() == reflect.Ptr && indirect(a.Type()) == timeType {
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+	if a.Type() == timeType {
+		return a.Interface().(time.Time).Equal(b.Interface().(time.Time))
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
@@ -0,0 +1,174 @@
+package duo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertStruct(t *testing.T) {
+	type User struct {
+		ID   int    `sql:"id,pk,auto"`
+		Name string `sql:"name"`
+		Nick string `sql:"nick,omitempty"`
+		Age  int
+	}
+	query, args := Dialect(Postgres).InsertStruct("users", &User{Name: "a8m"}).Query()
+	assert.Equal(t, `INSERT INTO "users" ("name", "age") VALUES ($1, $2)`, query)
+	assert.Equal(t, []any{"a8m", 0}, args)
+
+	query, args = Dialect(MySQL).InsertStruct("users", User{ID: 1, Name: "a8m", Nick: "a"}, WithUpsert()).Query()
+	assert.Equal(t, "INSERT INTO `users` (`id`, `name`, `nick`, `age`) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `nick` = VALUES(`nick`), `age` = VALUES(`age`)", query)
+	assert.Equal(t, []any{1, "a8m", "a", 0}, args)
+
+	b := Dialect(Postgres).InsertStruct("users", &User{ID: 1, Name: "a8m"}, WithUpsert())
+	query, args = b.Query()
+	assert.Equal(t, `INSERT INTO "users" ("id", "name", "age") VALUES ($1, $2, $3) ON CONFLICT ("id") DO UPDATE SET "name" = "excluded"."name", "age" = "excluded"."age"`, query)
+	assert.Equal(t, []any{1, "a8m", 0}, args)
+
+	// Primary keys without other columns are not updated.
+	type Tag struct {
+		Name string `sql:"name,pk"`
+	}
+	b = Dialect(Postgres).InsertStruct("tags", Tag{Name: "go"}, WithUpsert())
+	query, args = b.Query()
+	require.NoError(t, b.Err())
+	assert.Equal(t, `INSERT INTO "tags" ("name") VALUES ($1) ON CONFLICT ("name") DO UPDATE SET "name" = "tags"."name"`, query)
+	assert.Equal(t, []any{"go"}, args)
+	b = Dialect(MySQL).InsertStruct("tags", Tag{Name: "go"}, WithUpsert())
+	query, args = b.Query()
+	require.NoError(t, b.Err())
+	assert.Equal(t, "INSERT INTO `tags` (`name`) VALUES (?) ON DUPLICATE KEY UPDATE `name` = `tags`.`name`", query)
+	assert.Equal(t, []any{"go"}, args)
+
+	b = InsertStruct("users", 1)
+	require.EqualError(t, b.Err(), "sql: invalid type int. expect a struct or a pointer to a struct")
+	b = InsertStruct("users", nil)
+	require.EqualError(t, b.Err(), "sql: nil struct at index 0")
+	b = InsertStruct("users", (*User)(nil))
+	require.EqualError(t, b.Err(), "sql: nil struct at index 0")
+	b = Dialect(MySQL).InsertStructs("users", []any{User{}, nil})
+	require.EqualError(t, b.Err(), "sql: nil struct at index 1")
+
+	type Log struct {
+		Msg string
+	}
+	b = InsertStruct("logs", Log{}, WithUpsert())
+	require.EqualError(t, b.Err(), "sql: missing primary key field (`sql:\",pk\"`) in duo.Log for upsert")
+}
+
+func TestInsertStructs(t *testing.T) {
+	type User struct {
+		ID   int    `sql:"id,auto"`
+		Name string `sql:"name"`
+		Nick string `sql:"nick,omitempty"`
+	}
+	b := InsertStructs("users", []User{{Name: "a"}, {Name: "b", Nick: "x"}})
+	b.SetDialect(Postgres)
+	query, args := b.Query()
+	assert.Equal(t, `INSERT INTO "users" ("name", "nick") VALUES ($1, $2), ($3, $4)`, query)
+	assert.Equal(t, []any{"a", "", "b", "x"}, args)
+
+	query, args = Dialect(SQLite).InsertStructs("users", []*User{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}).Query()
+	assert.Equal(t, "INSERT INTO `users` (`id`, `name`) VALUES (?, ?), (?, ?)", query)
+	assert.Equal(t, []any{1, "a", 2, "b"}, args)
+
+	// Auto columns are omitted unless they are set in every row.
+	query, args = Dialect(SQLite).InsertStructs("users", []User{{ID: 1, Name: "a"}, {Name: "b"}}).Query()
+	assert.Equal(t, "INSERT INTO `users` (`name`) VALUES (?), (?)", query)
+	assert.Equal(t, []any{"a", "b"}, args)
+
+	query, args = Dialect(Postgres).InsertStructs("users", []User{{ID: 1, Name: "a"}, {ID: 2, Name: "b", Nick: "x"}}, WithUpsert()).Query()
+	assert.Equal(t, `INSERT INTO "users" ("id", "name", "nick") VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT ("id") DO UPDATE SET "name" = "excluded"."name", "nick" = "excluded"."nick"`, query)
+	assert.Equal(t, []any{1, "a", "", 2, "b", "x"}, args)
+
+	b = InsertStructs[User]("users", nil)
+	require.EqualError(t, b.Err(), "sql: no structs to insert")
+	b = Dialect(MySQL).InsertStructs("users", []any{User{}, &struct{ Name string }{}})
+	require.EqualError(t, b.Err(), "sql: mismatched struct types duo.User and struct { Name string }")
+}
+
+func TestUpdateStruct(t *testing.T) {
+	type User struct {
+		ID      int       `sql:"id,pk"`
+		Name    string    `sql:"name"`
+		Tags    []string  `sql:"tags,json"`
+		Updated time.Time `sql:"updated_at"`
+	}
+	now := time.Now()
+	orig := User{ID: 1, Name: "a8m", Tags: []string{"a"}, Updated: now}
+	mod := orig
+	mod.Name, mod.Tags, mod.Updated = "foo", []string{"a", "b"}, now.UTC()
+	query, args := Dialect(Postgres).UpdateStruct("users", orig, &mod).Query()
+	assert.Equal(t, `UPDATE "users" SET "name" = $1, "tags" = $2 WHERE "id" = $3`, query)
+	assert.Equal(t, []any{"foo", `["a","b"]`, 1}, args)
+
+	u := UpdateStruct("users", &orig, orig)
+	require.NoError(t, u.Err())
+	require.True(t, u.Empty())
+
+	u = UpdateStruct("users", orig, struct{ ID int }{})
+	require.EqualError(t, u.Err(), "sql: mismatched struct types duo.User and struct { ID int }")
+	u = UpdateStruct("users", struct{ Name string }{}, struct{ Name string }{"a"})
+	require.EqualError(t, u.Err(), "sql: missing primary key field (`sql:\",pk\"`) in struct { Name string } for update")
+	u = UpdateStruct("users", nil, orig)
+	require.EqualError(t, u.Err(), "sql: nil struct in update")
+	u = UpdateStruct("users", orig, (*User)(nil))
+	require.EqualError(t, u.Err(), "sql: nil struct in update")
+
+	type Post struct {
+		ID      int        `sql:"id,pk"`
+		Deleted *time.Time `sql:"deleted_at"`
+	}
+	local := now.In(time.FixedZone("UTC+2", 2*60*60))
+	require.True(t, UpdateStruct("posts", Post{ID: 1, Deleted: &now}, Post{ID: 1, Deleted: &local}).Empty())
+	require.True(t, UpdateStruct("posts", Post{ID: 1}, Post{ID: 1}).Empty())
+	query, args = Dialect(MySQL).UpdateStruct("posts", Post{ID: 1, Deleted: &now}, Post{ID: 1}).Query()
+	assert.Equal(t, "UPDATE `posts` SET `deleted_at` = ? WHERE `id` = ?", query)
+	assert.Equal(t, []any{(*time.Time)(nil), 1}, args)
+}
+
+func TestTracked(t *testing.T) {
+	type Member struct {
+		Org  int    `sql:"org_id,pk"`
+		User int    `sql:"user_id,pk"`
+		Role string `sql:"role"`
+	}
+	m := &Member{Org: 1, User: 2, Role: "user"}
+	tr := Track(m)
+	require.Same(t, m, tr.Entity())
+	changed, err := tr.Changed()
+	require.NoError(t, err)
+	require.Empty(t, changed)
+	require.True(t, tr.Update("members").Empty())
+
+	m.Role = "admin"
+	changed, err = tr.Changed()
+	require.NoError(t, err)
+	require.Equal(t, []string{"role"}, changed)
+	u := tr.Update("members")
+	u.SetDialect(MySQL)
+	query, args := u.Query()
+	assert.Equal(t, "UPDATE `members` SET `role` = ? WHERE `org_id` = ? AND `user_id` = ?", query)
+	assert.Equal(t, []any{"admin", 1, 2}, args)
+
+	tr.Reset()
+	changed, err = tr.Changed()
+	require.NoError(t, err)
+	require.Empty(t, changed)
+
+	type Event struct {
+		ID   int `sql:"id,pk"`
+		Data any `sql:"data,json"`
+	}
+	e := &Event{ID: 1}
+	te := Track(e)
+	e.Data = make(chan int)
+	_, err = te.Changed()
+	require.EqualError(t, err, `sql: marshal json field "data": json: unsupported type: chan int`)
+
+	_, err = Track(&struct{ Name string }{}).Changed()
+	require.EqualError(t, err, "sql: missing primary key field (`sql:\",pk\"`) in struct { Name string } for update")
+}
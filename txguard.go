@@ -0,0 +1,111 @@
+package duo
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrTxConcurrentUse is returned by a guarded transaction (see WithTxGuard)
+// when a statement is issued while another statement is still in progress.
+var ErrTxConcurrentUse = errors.New("sql/tx: concurrent use of transaction")
+
+// WithTxGuard enables a debug guard on the transactions started by the driver.
+// The guard detects statements that are executed concurrently on the same Tx,
+// and on MySQL, statements that are issued while Rows returned by a previous
+// Tx.Query are still open. Instead of failing with a driver-level error (e.g.
+// "busy buffer"), such statements fail with an error wrapping ErrTxConcurrentUse.
+//
+// Note that only Rows returned by Tx.Query are tracked, since the *sql.Rows
+// returned by Tx.QueryContext cannot be observed by the guard.
+func WithTxGuard() Option {
+	return func(d *Driver) {
+		d.txGuard = true
+	}
+}
+
+// txGuard detects overlapping statements on a transaction.
+type txGuard struct {
+	mu      sync.Mutex
+	dialect string
+	// active holds the statement in progress (if any).
+	active string
+	// rows holds the open Rows of the transaction.
+	rows map[*guardedRows]struct{}
+}
+
+// enter marks the start of the given statement, and returns
+// a function that should be called when the statement is done.
+func (g *txGuard) enter(query string) (func(), error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.active != "" {
+		return nil, fmt.Errorf("%w: statement %q was issued while %q is in progress", ErrTxConcurrentUse, query, g.active)
+	}
+	// MySQL connections cannot run a statement while
+	// the result of a previous query is being read.
+	if g.dialect == MySQL && len(g.rows) > 0 {
+		var open []string
+		for r := range g.rows {
+			open = append(open, r.query)
+		}
+		return nil, fmt.Errorf("%w: statement %q was issued while rows of %q are open", ErrTxConcurrentUse, query, open)
+	}
+	g.active = query
+	return func() {
+		g.mu.Lock()
+		g.active = ""
+		g.mu.Unlock()
+	}, nil
+}
+
+// trackRows wraps the given Rows and tracks them until they are exhausted or closed.
+func (g *txGuard) trackRows(rows ColumnScanner, query string) ColumnScanner {
+	r := &guardedRows{ColumnScanner: rows, guard: g, query: query}
+	r.open(true)
+	return r
+}
+
+// guardedRows reports its state to the transaction guard.
+type guardedRows struct {
+	ColumnScanner
+	guard *txGuard
+	query string
+}
+
+// open adds or removes the rows from the open set of the guard.
+func (r *guardedRows) open(open bool) {
+	r.guard.mu.Lock()
+	defer r.guard.mu.Unlock()
+	switch {
+	case !open:
+		delete(r.guard.rows, r)
+	case r.guard.rows == nil:
+		r.guard.rows = map[*guardedRows]struct{}{r: {}}
+	default:
+		r.guard.rows[r] = struct{}{}
+	}
+}
+
+// Next prepares the next result row. The rows are considered
+// closed when the result set is exhausted, as in database/sql.
+func (r *guardedRows) Next() bool {
+	if r.ColumnScanner.Next() {
+		return true
+	}
+	r.open(false)
+	return false
+}
+
+// NextResultSet prepares the next result set for reading.
+func (r *guardedRows) NextResultSet() bool {
+	ok := r.ColumnScanner.NextResultSet()
+	r.open(ok)
+	return ok
+}
+
+// Close closes the underlying Rows.
+func (r *guardedRows) Close() error {
+	r.open(false)
+	return r.ColumnScanner.Close()
+}
@@ -0,0 +1,78 @@
+package duo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxGuard(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	drv, err := OpenDB(MySQL, db, WithTxGuard())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	tx, err := drv.Tx(ctx)
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	var rows Rows
+	require.NoError(t, tx.Query(ctx, "SELECT id FROM users", []any{}, &rows))
+	require.True(t, rows.Next())
+
+	err = tx.Exec(ctx, "UPDATE users SET age = 1", []any{}, nil)
+	require.ErrorIs(t, err, ErrTxConcurrentUse)
+	assert.Contains(t, err.Error(), "SELECT id FROM users")
+
+	// Exhausted rows are closed by database/sql.
+	for rows.Next() {
+	}
+	mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 2))
+	require.NoError(t, tx.Exec(ctx, "UPDATE users SET age = 1", []any{}, nil))
+	mock.ExpectCommit()
+	require.NoError(t, tx.Commit())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTxGuard_Overlap(t *testing.T) {
+	g := &txGuard{dialect: Postgres}
+	leave, err := g.enter("UPDATE users SET age = 1")
+	require.NoError(t, err)
+	_, err = g.enter("SELECT 1")
+	require.ErrorIs(t, err, ErrTxConcurrentUse)
+	leave()
+	leave, err = g.enter("SELECT 1")
+	require.NoError(t, err)
+	leave()
+}
+
+func TestTxGuard_LeakTracking(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	drv, err := OpenDB(MySQL, db, WithLeakTracking(), WithTxGuard())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	tx, err := drv.Tx(ctx)
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	var rows Rows
+	require.NoError(t, tx.Query(ctx, "SELECT id FROM users", []any{}, &rows))
+	err = tx.Exec(ctx, "UPDATE users SET age = 1", []any{}, nil)
+	require.ErrorIs(t, err, ErrTxConcurrentUse)
+	for rows.Next() {
+	}
+	require.NoError(t, rows.Close())
+
+	mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, tx.Exec(ctx, "UPDATE users SET age = 1", []any{}, nil))
+	mock.ExpectCommit()
+	require.NoError(t, tx.Commit())
+	assert.Empty(t, drv.Leaks(0))
+	require.NoError(t, mock.ExpectationsWereMet())
+}